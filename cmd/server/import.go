@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/boxoffice"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/config"
+	httpserver "github.com/Clark-Hu/Robin-Camp-Clark/internal/http"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+// runImport implements `movies-api import [flags] FILE`. It streams the same
+// per-row report as POST /movies:import to stdout and fails when any row
+// could not be imported.
+func runImport(ctx context.Context, cfg config.Config, logger *log.Logger, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "input format: csv or ndjson (default: from the file extension)")
+	onConflict := fs.String("on-conflict", string(httpserver.ImportConflictSkip), "existing title+year: skip or upsert")
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing")
+	enrich := fs.Bool("enrich", true, "fetch box office data for imported movies after the import")
+	actor := fs.String("actor", "import", "actor recorded on movie revisions")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: movies-api import [flags] FILE")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one input file")
+	}
+	path := fs.Arg(0)
+
+	opts := httpserver.ImportOptions{OnConflict: httpserver.ImportConflictMode(strings.ToLower(*onConflict)), DryRun: *dryRun, Enrich: *enrich}
+	if opts.OnConflict != httpserver.ImportConflictSkip && opts.OnConflict != httpserver.ImportConflictUpsert {
+		return fmt.Errorf("invalid -on-conflict value %q", *onConflict)
+	}
+	importFormat := httpserver.ImportFormat(strings.ToLower(*format))
+	if importFormat == "" {
+		guessed, ok := httpserver.ImportFormatForPath(path)
+		if !ok {
+			return fmt.Errorf("cannot infer format of %s; pass -format", path)
+		}
+		importFormat = guessed
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	st, err := openStore(ctx, cfg, logger)
+	if err != nil {
+		return fmt.Errorf("connect database: %w", err)
+	}
+	defer st.Close()
+
+	boxClient, err := boxoffice.NewHTTPClient(cfg.BoxOfficeURL, cfg.BoxOfficeAPIKey, time.Duration(cfg.BoxOfficeTimeoutSecs)*time.Second, logger)
+	if err != nil {
+		return fmt.Errorf("init box office client: %w", err)
+	}
+	server := httpserver.New(cfg, st, repository.New(st), boxClient, logger)
+
+	ctx = repository.WithActor(ctx, *actor)
+	report, err := server.WriteImportReport(ctx, os.Stdout, file, importFormat, opts)
+	// Enrichment runs while the import does; wait for what is still queued.
+	if shutdownErr := server.Shutdown(ctx); shutdownErr != nil {
+		logger.Printf("import: box office enrichment interrupted: %v", shutdownErr)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Printf("import: %d rows, %d created, %d updated, %d skipped, %d failed",
+		report.Total, report.Created, report.Updated, report.Skipped, report.Failed)
+	if report.Error != "" {
+		return fmt.Errorf("import stopped after %d rows: %s", report.Total, report.Error)
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d rows failed", report.Failed)
+	}
+	return nil
+}
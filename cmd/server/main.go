@@ -13,6 +13,7 @@ import (
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/boxoffice"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/config"
 	httpserver "github.com/Clark-Hu/Robin-Camp-Clark/internal/http"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/jobs"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/store"
 )
@@ -28,20 +29,16 @@ func main() {
 
 	logger := log.New(os.Stdout, "[movies-api] ", log.LstdFlags|log.Lshortfile)
 
-	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
-
-	storeOpts := store.Options{
-		MaxConns:               int32(cfg.DBMaxConns),
-		MinConns:               int32(cfg.DBMinConns),
-		MaxConnIdleTime:        time.Duration(cfg.DBMaxIdleSecs) * time.Second,
-		MaxConnLifetime:        time.Duration(cfg.DBMaxLifeSecs) * time.Second,
-		ConnTimeout:            time.Duration(cfg.DBConnTimeoutSecs) * time.Second,
-		StatementCacheCapacity: cfg.DBStatementCache,
-		Logger:                 logger,
+	// `movies-api import [flags] FILE` loads a local CSV/NDJSON file and exits.
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		logger.SetOutput(os.Stderr)
+		if err := runImport(ctx, cfg, logger, os.Args[2:]); err != nil {
+			log.Fatalf("import: %v", err)
+		}
+		return
 	}
 
-	st, err := store.New(dbCtx, cfg.DBURL, storeOpts)
+	st, err := openStore(ctx, cfg, logger)
 	if err != nil {
 		log.Fatalf("connect database: %v", err)
 	}
@@ -55,6 +52,18 @@ func main() {
 	repo := repository.New(st)
 	server := httpserver.New(cfg, st, repo, boxClient, logger)
 
+	purger := jobs.NewTrashPurger(repo.Movies,
+		time.Duration(cfg.TrashRetentionHours)*time.Hour,
+		time.Duration(cfg.TrashPurgeEveryMins)*time.Minute,
+		logger)
+	go purger.Run(ctx)
+
+	idempotencyPurger := jobs.NewIdempotencyPurger(repo.Idempotency,
+		time.Duration(cfg.IdempotencyTTLHours)*time.Hour,
+		time.Hour,
+		logger)
+	go idempotencyPurger.Run(ctx)
+
 	serverErrCh := make(chan error, 1)
 	go func() {
 		if err := server.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
@@ -78,3 +87,20 @@ func main() {
 		log.Printf("graceful shutdown error: %v", err)
 	}
 }
+
+// openStore connects the database pool configured by cfg.
+func openStore(ctx context.Context, cfg config.Config, logger *log.Logger) (*store.Store, error) {
+	dbCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	storeOpts := store.Options{
+		MaxConns:               int32(cfg.DBMaxConns),
+		MinConns:               int32(cfg.DBMinConns),
+		MaxConnIdleTime:        time.Duration(cfg.DBMaxIdleSecs) * time.Second,
+		MaxConnLifetime:        time.Duration(cfg.DBMaxLifeSecs) * time.Second,
+		ConnTimeout:            time.Duration(cfg.DBConnTimeoutSecs) * time.Second,
+		StatementCacheCapacity: cfg.DBStatementCache,
+		Logger:                 logger,
+	}
+	return store.New(dbCtx, cfg.DBURL, storeOpts)
+}
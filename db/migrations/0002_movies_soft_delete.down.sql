@@ -0,0 +1,5 @@
+DELETE FROM movies WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_movies_deleted_at;
+DROP INDEX IF EXISTS uq_movies_title;
+ALTER TABLE movies ADD CONSTRAINT uq_movies_title UNIQUE (title);
+ALTER TABLE movies DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,9 @@
+-- Soft delete for movies: rows are tombstoned via deleted_at and purged later.
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Titles only need to be unique among live movies so a deleted title can be reused.
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS uq_movies_title;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movies_title ON movies (title) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_movies_deleted_at ON movies (deleted_at) WHERE deleted_at IS NOT NULL;
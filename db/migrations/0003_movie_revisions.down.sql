@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS movie_revisions;
+DROP FUNCTION IF EXISTS reject_movie_revision_mutation();
@@ -0,0 +1,27 @@
+-- Append-only audit trail of movie changes with field-level diffs.
+
+CREATE TABLE IF NOT EXISTS movie_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL,
+    actor TEXT NOT NULL,
+    source TEXT NOT NULL CHECK (source IN ('user', 'boxoffice')),
+    changed_fields TEXT[] NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- No foreign key on purpose: history outlives purged movies.
+CREATE INDEX IF NOT EXISTS idx_movie_revisions_movie_id ON movie_revisions (movie_id, id DESC);
+
+CREATE OR REPLACE FUNCTION reject_movie_revision_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'movie_revisions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_movie_revisions_append_only ON movie_revisions;
+CREATE TRIGGER trg_movie_revisions_append_only
+BEFORE UPDATE OR DELETE ON movie_revisions
+FOR EACH ROW EXECUTE FUNCTION reject_movie_revision_mutation();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_movies_title_year;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movies_title ON movies (title) WHERE deleted_at IS NULL;
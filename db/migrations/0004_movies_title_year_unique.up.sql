@@ -0,0 +1,4 @@
+-- Allow remakes: titles are unique per release year (case-insensitive) among live movies.
+
+DROP INDEX IF EXISTS uq_movies_title;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movies_title_year ON movies (lower(title), release_year) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS movie_slug_history;
+DROP INDEX IF EXISTS uq_movies_slug;
+ALTER TABLE movies DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,35 @@
+-- Stable URL slugs. A movie keeps every slug it has been published under:
+-- the current one lives on movies.slug, earlier ones redirect via movie_slug_history.
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS slug TEXT;
+
+UPDATE movies m
+SET slug = s.slug
+FROM (
+    SELECT id,
+           base || CASE WHEN rn > 1 THEN '-' || rn ELSE '' END AS slug
+    FROM (
+        SELECT id,
+               base,
+               row_number() OVER (PARTITION BY base ORDER BY created_at, id) AS rn
+        FROM (
+            SELECT id,
+                   created_at,
+                   COALESCE(NULLIF(trim(both '-' from regexp_replace(lower(title), '[^[:alnum:]]+', '-', 'g')), ''), 'movie')
+                       || '-' || release_year AS base
+            FROM movies
+        ) b
+    ) r
+) s
+WHERE m.id = s.id AND m.slug IS NULL;
+
+ALTER TABLE movies ALTER COLUMN slug SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movies_slug ON movies (slug);
+
+CREATE TABLE IF NOT EXISTS movie_slug_history (
+    slug TEXT PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_slug_history_movie_id ON movie_slug_history (movie_id);
@@ -0,0 +1,15 @@
+-- Alternate titles (original-language, localized, working) used for search and lookups.
+
+CREATE TABLE IF NOT EXISTS movie_titles (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    title TEXT NOT NULL CHECK (btrim(title) <> ''),
+    language TEXT,
+    region TEXT,
+    kind TEXT NOT NULL CHECK (kind IN ('original', 'localized', 'working')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movie_titles_variant
+    ON movie_titles (movie_id, lower(title), COALESCE(language, ''), COALESCE(region, ''));
+CREATE INDEX IF NOT EXISTS idx_movie_titles_lower_title ON movie_titles (lower(title));
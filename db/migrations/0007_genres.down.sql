@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS movie_genres;
+DROP TABLE IF EXISTS genre_synonyms;
+DROP TABLE IF EXISTS genres;
@@ -0,0 +1,89 @@
+-- Managed genre taxonomy: canonical genres, their synonyms and a many-to-many
+-- link to movies. movies.genre keeps the primary (first) canonical genre.
+
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_genres_name ON genres (lower(name));
+
+-- Every spelling that resolves to a genre, including its canonical name. The
+-- key is the lower-cased spelling with everything but letters and digits
+-- removed, so "Sci-Fi", "sci fi" and "SciFi" share the key "scifi".
+CREATE TABLE IF NOT EXISTS genre_synonyms (
+    key TEXT PRIMARY KEY CHECK (key <> ''),
+    genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_genre_synonyms_genre_id ON genre_synonyms (genre_id);
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    genre_id INTEGER NOT NULL REFERENCES genres(id) ON DELETE RESTRICT,
+    position SMALLINT NOT NULL,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_genres_genre_id ON movie_genres (genre_id, movie_id);
+
+-- Seed taxonomy -----------------------------------------------------------------
+INSERT INTO genres (name) VALUES
+    ('Action'), ('Adventure'), ('Animation'), ('Comedy'), ('Crime'), ('Documentary'),
+    ('Drama'), ('Family'), ('Fantasy'), ('History'), ('Horror'), ('Music'), ('Mystery'),
+    ('Romance'), ('Science Fiction'), ('Thriller'), ('War'), ('Western')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genre_synonyms (key, genre_id)
+SELECT s.key, g.id
+FROM (VALUES
+    ('animated', 'Animation'),
+    ('cartoon', 'Animation'),
+    ('comedic', 'Comedy'),
+    ('doc', 'Documentary'),
+    ('docu', 'Documentary'),
+    ('historical', 'History'),
+    ('musical', 'Music'),
+    ('romantic', 'Romance'),
+    ('scifi', 'Science Fiction'),
+    ('sf', 'Science Fiction'),
+    ('suspense', 'Thriller')
+) AS s(key, name)
+JOIN genres g ON lower(g.name) = lower(s.name)
+ON CONFLICT DO NOTHING;
+
+-- Backfill ------------------------------------------------------------------------
+-- Free-text genres already in use that match nothing become genres of their own.
+INSERT INTO genres (name)
+SELECT DISTINCT ON (regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g')) btrim(m.genre)
+FROM movies m
+WHERE regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g') <> ''
+  AND NOT EXISTS (
+      SELECT 1 FROM genres g
+      WHERE regexp_replace(lower(g.name), '[^[:alnum:]]+', '', 'g') = regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g')
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM genre_synonyms s
+      WHERE s.key = regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g')
+  )
+ORDER BY regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g'), m.created_at
+ON CONFLICT DO NOTHING;
+
+INSERT INTO genre_synonyms (key, genre_id)
+SELECT regexp_replace(lower(name), '[^[:alnum:]]+', '', 'g'), id
+FROM genres
+WHERE regexp_replace(lower(name), '[^[:alnum:]]+', '', 'g') <> ''
+ON CONFLICT DO NOTHING;
+
+INSERT INTO movie_genres (movie_id, genre_id, position)
+SELECT m.id, s.genre_id, 0
+FROM movies m
+JOIN genre_synonyms s ON s.key = regexp_replace(lower(m.genre), '[^[:alnum:]]+', '', 'g')
+ON CONFLICT DO NOTHING;
+
+UPDATE movies m
+SET genre = g.name
+FROM movie_genres mg
+JOIN genres g ON g.id = mg.genre_id
+WHERE mg.movie_id = m.id AND mg.position = 0 AND m.genre <> g.name;
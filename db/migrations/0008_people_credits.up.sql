@@ -0,0 +1,35 @@
+-- People and their credits on movies (cast and crew).
+
+CREATE TABLE IF NOT EXISTS people (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL CHECK (btrim(name) <> ''),
+    birth_date DATE,
+    biography TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+DROP TRIGGER IF EXISTS trg_people_set_updated_at ON people;
+CREATE TRIGGER trg_people_set_updated_at
+BEFORE UPDATE ON people
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE INDEX IF NOT EXISTS idx_people_lower_name ON people (lower(name), id);
+CREATE INDEX IF NOT EXISTS idx_people_name_trgm ON people USING GIN (name gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    -- RESTRICT: a person with credits must have them removed before deletion.
+    person_id UUID NOT NULL REFERENCES people(id) ON DELETE RESTRICT,
+    role TEXT NOT NULL CHECK (role IN (
+        'director', 'writer', 'producer', 'actor', 'composer', 'cinematographer', 'editor'
+    )),
+    character TEXT,
+    billing_order INTEGER NOT NULL CHECK (billing_order >= 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movie_credits_entry
+    ON movie_credits (movie_id, person_id, role, COALESCE(character, ''));
+CREATE INDEX IF NOT EXISTS idx_movie_credits_movie_id ON movie_credits (movie_id, billing_order);
+CREATE INDEX IF NOT EXISTS idx_movie_credits_person_id ON movie_credits (person_id, role);
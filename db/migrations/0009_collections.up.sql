@@ -0,0 +1,26 @@
+-- Collections (franchises, trilogies, curated lists) with ordered membership.
+
+CREATE TABLE IF NOT EXISTS collections (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL CHECK (btrim(name) <> ''),
+    description TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_collections_name ON collections (lower(name));
+
+DROP TRIGGER IF EXISTS trg_collections_set_updated_at ON collections;
+CREATE TRIGGER trg_collections_set_updated_at
+BEFORE UPDATE ON collections
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS collection_movies (
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position >= 0),
+    PRIMARY KEY (collection_id, movie_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_collection_movies_movie_id ON collection_movies (movie_id);
+CREATE INDEX IF NOT EXISTS idx_collection_movies_position ON collection_movies (collection_id, position);
@@ -0,0 +1,15 @@
+-- Descriptive metadata: runtime, languages, production countries, synopsis and poster.
+-- Language codes are ISO 639-1 (lower case), country codes ISO 3166-1 alpha-2
+-- (upper case); the API validates them against the full code lists.
+
+ALTER TABLE movies
+    ADD COLUMN IF NOT EXISTS runtime_minutes INTEGER CHECK (runtime_minutes IS NULL OR runtime_minutes > 0),
+    ADD COLUMN IF NOT EXISTS original_language TEXT CHECK (original_language IS NULL OR original_language ~ '^[a-z]{2}$'),
+    ADD COLUMN IF NOT EXISTS spoken_languages TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS production_countries TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS synopsis TEXT,
+    ADD COLUMN IF NOT EXISTS poster_url TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_movies_original_language ON movies (original_language);
+CREATE INDEX IF NOT EXISTS idx_movies_spoken_languages ON movies USING GIN (spoken_languages);
+CREATE INDEX IF NOT EXISTS idx_movies_production_countries ON movies USING GIN (production_countries);
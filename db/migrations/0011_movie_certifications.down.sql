@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS movie_certifications;
+
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS chk_movies_mpa_rating;
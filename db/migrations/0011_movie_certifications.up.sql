@@ -0,0 +1,32 @@
+-- Canonical MPA ratings plus per-country certifications (BBFC, FSK, ...).
+-- Free-form MPA values are folded onto the canonical spelling; values that
+-- match no rating are cleared so the constraint can be enforced.
+
+UPDATE movies
+SET mpa_rating = CASE upper(regexp_replace(mpa_rating, '[\s_-]', '', 'g'))
+        WHEN 'G' THEN 'G'
+        WHEN 'PG' THEN 'PG'
+        WHEN 'PG13' THEN 'PG-13'
+        WHEN 'R' THEN 'R'
+        WHEN 'NC17' THEN 'NC-17'
+        WHEN 'NR' THEN 'NR'
+        WHEN 'UNRATED' THEN 'NR'
+        WHEN 'NOTRATED' THEN 'NR'
+    END
+WHERE mpa_rating IS NOT NULL
+  AND mpa_rating NOT IN ('G', 'PG', 'PG-13', 'R', 'NC-17', 'NR');
+
+ALTER TABLE movies DROP CONSTRAINT IF EXISTS chk_movies_mpa_rating;
+ALTER TABLE movies ADD CONSTRAINT chk_movies_mpa_rating
+    CHECK (mpa_rating IS NULL OR mpa_rating IN ('G', 'PG', 'PG-13', 'R', 'NC-17', 'NR'));
+
+-- The US rating stays in movies.mpa_rating; other countries live here.
+CREATE TABLE IF NOT EXISTS movie_certifications (
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    country TEXT NOT NULL CHECK (country ~ '^[A-Z]{2}$' AND country <> 'US'),
+    system TEXT NOT NULL,
+    rating TEXT NOT NULL CHECK (btrim(rating) <> ''),
+    PRIMARY KEY (movie_id, country)
+);
+
+CREATE INDEX IF NOT EXISTS idx_movie_certifications_country_rating ON movie_certifications (country, rating);
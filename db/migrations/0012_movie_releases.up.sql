@@ -0,0 +1,13 @@
+-- Regional release dates. movies.release_date remains the primary/worldwide
+-- date; these rows record when and how the movie came out in each country.
+
+CREATE TABLE IF NOT EXISTS movie_releases (
+    id BIGSERIAL PRIMARY KEY,
+    movie_id UUID NOT NULL REFERENCES movies(id) ON DELETE CASCADE,
+    country TEXT NOT NULL CHECK (country ~ '^[A-Z]{2}$'),
+    release_date DATE NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('theatrical', 'digital', 'festival'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_movie_releases_entry ON movie_releases (movie_id, country, type);
+CREATE INDEX IF NOT EXISTS idx_movie_releases_country_date ON movie_releases (country, release_date);
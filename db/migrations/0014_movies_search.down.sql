@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS trg_movie_genres_refresh_search ON movie_genres;
+DROP TRIGGER IF EXISTS trg_movie_titles_refresh_search ON movie_titles;
+DROP TRIGGER IF EXISTS trg_movies_set_search_vector ON movies;
+DROP FUNCTION IF EXISTS refresh_movie_search_vector();
+DROP FUNCTION IF EXISTS set_movie_search_vector();
+DROP FUNCTION IF EXISTS movie_search_vector(UUID, TEXT, TEXT);
+DROP INDEX IF EXISTS idx_movies_search_vector;
+ALTER TABLE movies DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,64 @@
+-- Full-text search document per movie: title (A), alternate titles (B),
+-- distributor (C) and genre names (D). It spans other tables, so triggers keep
+-- it current instead of a generated column.
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+
+CREATE OR REPLACE FUNCTION movie_search_vector(movie_id UUID, title TEXT, distributor TEXT)
+RETURNS TSVECTOR AS $$
+    SELECT setweight(to_tsvector('simple', COALESCE(title, '')), 'A')
+        || setweight(to_tsvector('simple', COALESCE((
+               SELECT string_agg(t.title, ' ') FROM movie_titles t WHERE t.movie_id = $1
+           ), '')), 'B')
+        || setweight(to_tsvector('simple', COALESCE(distributor, '')), 'C')
+        || setweight(to_tsvector('simple', COALESCE((
+               SELECT string_agg(g.name, ' ')
+               FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+               WHERE mg.movie_id = $1
+           ), '')), 'D')
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION set_movie_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector = movie_search_vector(NEW.id, NEW.title, NEW.distributor);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_movies_set_search_vector ON movies;
+CREATE TRIGGER trg_movies_set_search_vector
+BEFORE INSERT OR UPDATE OF title, distributor, search_vector ON movies
+FOR EACH ROW EXECUTE FUNCTION set_movie_search_vector();
+
+-- Alternate titles and genres change after the movie row is written; touching
+-- search_vector makes the trigger above recompute it.
+CREATE OR REPLACE FUNCTION refresh_movie_search_vector()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE movies SET search_vector = NULL WHERE id = OLD.movie_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.movie_id IS DISTINCT FROM OLD.movie_id) THEN
+        UPDATE movies SET search_vector = NULL WHERE id = NEW.movie_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_movie_titles_refresh_search ON movie_titles;
+CREATE TRIGGER trg_movie_titles_refresh_search
+AFTER INSERT OR UPDATE OR DELETE ON movie_titles
+FOR EACH ROW EXECUTE FUNCTION refresh_movie_search_vector();
+
+DROP TRIGGER IF EXISTS trg_movie_genres_refresh_search ON movie_genres;
+CREATE TRIGGER trg_movie_genres_refresh_search
+AFTER INSERT OR UPDATE OR DELETE ON movie_genres
+FOR EACH ROW EXECUTE FUNCTION refresh_movie_search_vector();
+
+-- Backfill without bumping updated_at (and so every ETag).
+ALTER TABLE movies DISABLE TRIGGER trg_movies_set_updated_at;
+UPDATE movies SET search_vector = NULL;
+ALTER TABLE movies ENABLE TRIGGER trg_movies_set_updated_at;
+
+CREATE INDEX IF NOT EXISTS idx_movies_search_vector ON movies USING GIN (search_vector);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_movies_title_prefix;
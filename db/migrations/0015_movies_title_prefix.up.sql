@@ -0,0 +1,5 @@
+-- Byte-ordered index on the lower-cased title so title prefixes can be looked
+-- up with a range scan; the trigram index only helps from three characters on.
+CREATE INDEX IF NOT EXISTS idx_movies_title_prefix
+    ON movies ((lower(title) COLLATE "C"))
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_movies_revenue_desc;
+DROP INDEX IF EXISTS idx_movies_revenue_asc;
+DROP INDEX IF EXISTS idx_movies_budget_desc;
+DROP INDEX IF EXISTS idx_movies_budget_asc;
+CREATE INDEX IF NOT EXISTS idx_movies_budget ON movies (budget);
+DROP INDEX IF EXISTS idx_movies_title_sort;
+DROP INDEX IF EXISTS idx_movies_release_date_id;
+DROP INDEX IF EXISTS idx_movies_created_at_id;
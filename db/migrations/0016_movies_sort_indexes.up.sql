@@ -0,0 +1,18 @@
+-- Keyset pagination paths for GET /movies?sort=... . Each index ends in id,
+-- the tie-breaker of every sort. Non-null keys are scanned in either
+-- direction; nullable keys keep movies without a value last both ways, which
+-- needs one index per direction.
+CREATE INDEX IF NOT EXISTS idx_movies_created_at_id ON movies (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_release_date_id ON movies (release_date, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_title_sort ON movies (lower(title), id) WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS idx_movies_budget;
+CREATE INDEX IF NOT EXISTS idx_movies_budget_asc ON movies (budget ASC NULLS LAST, id ASC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_budget_desc ON movies (budget DESC NULLS LAST, id DESC) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_movies_revenue_asc
+    ON movies (((box_office #>> '{revenue,worldwide}')::bigint) ASC NULLS LAST, id ASC)
+    WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_movies_revenue_desc
+    ON movies (((box_office #>> '{revenue,worldwide}')::bigint) DESC NULLS LAST, id DESC)
+    WHERE deleted_at IS NULL;
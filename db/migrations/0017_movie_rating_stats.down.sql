@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_ratings_apply_stats ON ratings;
+DROP FUNCTION IF EXISTS apply_rating_to_stats();
+DROP TABLE IF EXISTS movie_rating_stats;
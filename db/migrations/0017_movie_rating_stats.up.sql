@@ -0,0 +1,50 @@
+-- Per-movie rating totals kept in step with ratings by trigger, so listings
+-- can filter and sort by rating without aggregating on every request.
+-- Totals are adjusted incrementally; concurrent ratings of a movie serialize
+-- on its stats row instead of overwriting each other's recount.
+CREATE TABLE IF NOT EXISTS movie_rating_stats (
+    movie_id UUID PRIMARY KEY REFERENCES movies(id) ON DELETE CASCADE,
+    rating_count BIGINT NOT NULL DEFAULT 0,
+    rating_sum NUMERIC NOT NULL DEFAULT 0,
+    rating_average NUMERIC GENERATED ALWAYS AS (
+        CASE WHEN rating_count > 0 THEN rating_sum / rating_count END
+    ) STORED
+);
+
+CREATE OR REPLACE FUNCTION apply_rating_to_stats()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        -- A no-op when the movie itself is being deleted.
+        UPDATE movie_rating_stats
+        SET rating_count = rating_count - 1,
+            rating_sum = rating_sum - OLD.rating
+        WHERE movie_id = OLD.movie_id;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO movie_rating_stats (movie_id, rating_count, rating_sum)
+        VALUES (NEW.movie_id, 1, NEW.rating)
+        ON CONFLICT (movie_id) DO UPDATE
+            SET rating_count = movie_rating_stats.rating_count + 1,
+                rating_sum = movie_rating_stats.rating_sum + EXCLUDED.rating_sum;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_ratings_apply_stats ON ratings;
+CREATE TRIGGER trg_ratings_apply_stats
+AFTER INSERT OR UPDATE OF movie_id, rating OR DELETE ON ratings
+FOR EACH ROW EXECUTE FUNCTION apply_rating_to_stats();
+
+INSERT INTO movie_rating_stats (movie_id, rating_count, rating_sum)
+SELECT movie_id, COUNT(*), SUM(rating)
+FROM ratings
+GROUP BY movie_id
+ON CONFLICT (movie_id) DO NOTHING;
+
+-- sort=rating in both directions; unrated movies come last either way.
+CREATE INDEX IF NOT EXISTS idx_movie_rating_stats_average_asc
+    ON movie_rating_stats (rating_average ASC NULLS LAST, movie_id ASC);
+CREATE INDEX IF NOT EXISTS idx_movie_rating_stats_average_desc
+    ON movie_rating_stats (rating_average DESC NULLS LAST, movie_id DESC);
@@ -0,0 +1,5 @@
+-- When an in-flight key was claimed. A claim whose request never completed
+-- (e.g. the process died before storing the response) is taken over by a
+-- retry once it is older than the in-progress lease, instead of blocking the
+-- key until it expires.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/certification"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
 )
 
@@ -24,6 +25,7 @@ type Result struct {
 	Budget      *int64
 	MpaRating   *string
 	BoxOffice   *domain.BoxOffice
+	Releases    []domain.Release
 }
 
 // Client defines the contract for querying the upstream box office API.
@@ -151,11 +153,30 @@ func convertToResult(payload apiResponse) *Result {
 		LastUpdated: lastUpdated,
 	}
 
+	// Upstream spellings vary ("PG13", "Unrated"); anything that is not an
+	// MPA rating is dropped rather than stored.
+	var mpaRating *string
+	if payload.MpaRating != nil {
+		if rating, ok := certification.NormalizeMPA(*payload.MpaRating); ok {
+			mpaRating = &rating
+		}
+	}
+
+	// Upstream reports the North American theatrical date; record it as the
+	// US theatrical release.
+	var releases []domain.Release
+	if payload.ReleaseDate != nil {
+		if date, err := time.Parse("2006-01-02", *payload.ReleaseDate); err == nil {
+			releases = append(releases, domain.Release{Country: "US", Date: date, Type: domain.ReleaseTypeTheatrical})
+		}
+	}
+
 	return &Result{
 		Distributor: payload.Distributor,
 		Budget:      payload.Budget,
-		MpaRating:   payload.MpaRating,
+		MpaRating:   mpaRating,
 		BoxOffice:   boxOffice,
+		Releases:    releases,
 	}
 }
 
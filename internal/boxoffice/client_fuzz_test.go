@@ -3,15 +3,19 @@ package boxoffice
 import (
 	"testing"
 	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/certification"
 )
 
 func FuzzConvertToResult(f *testing.F) {
-	f.Add(int64(1000000), int64(500000), "Warner Bros", "USD", "BoxAPI")
+	f.Add(int64(1000000), int64(500000), "Warner Bros", "USD", "BoxAPI", "pg13")
+	f.Add(int64(2), int64(0), "", "", "", "Unrated")
 
-	f.Fuzz(func(t *testing.T, worldwide, budget int64, distributor, currency, source string) {
+	f.Fuzz(func(t *testing.T, worldwide, budget int64, distributor, currency, source, mpaRating string) {
 		resp := apiResponse{
 			Distributor: optionalString(distributor),
 			Budget:      &budget,
+			MpaRating:   optionalString(mpaRating),
 			Revenue: revenuePayload{
 				Worldwide: &worldwide,
 			},
@@ -40,6 +44,11 @@ func FuzzConvertToResult(f *testing.F) {
 		if result.BoxOffice.Source == "" {
 			t.Fatalf("source should never be empty")
 		}
+		if result.MpaRating != nil {
+			if canonical, ok := certification.NormalizeMPA(*result.MpaRating); !ok || canonical != *result.MpaRating {
+				t.Fatalf("mpaRating %q is not canonical", *result.MpaRating)
+			}
+		}
 	})
 }
 
@@ -0,0 +1,67 @@
+// Package certification normalizes age ratings: the MPA rating stored on every
+// movie and the national certification systems recorded per country.
+package certification
+
+import "strings"
+
+// MPARatings lists the canonical MPA ratings. NR covers unrated releases.
+var MPARatings = []string{"G", "PG", "PG-13", "R", "NC-17", "NR"}
+
+// System is the certification scheme used in one country.
+type System struct {
+	Country string
+	Name    string
+	Ratings []string
+
+	aliases map[string]string
+}
+
+var systems = map[string]System{
+	"US": {Country: "US", Name: "MPA", Ratings: MPARatings, aliases: map[string]string{"UNRATED": "NR", "NOTRATED": "NR"}},
+	"GB": {Country: "GB", Name: "BBFC", Ratings: []string{"U", "PG", "12A", "12", "15", "18", "R18"}},
+	"DE": {Country: "DE", Name: "FSK", Ratings: []string{"0", "6", "12", "16", "18"}},
+	// Mainland China has no age tiers: a release either holds a public
+	// screening permit or is not shown.
+	"CN": {Country: "CN", Name: "NRTA", Ratings: []string{"Approved"}},
+}
+
+// Lookup returns the system used in country, given as an ISO 3166-1 alpha-2
+// code in any case.
+func Lookup(country string) (System, bool) {
+	sys, ok := systems[strings.ToUpper(strings.TrimSpace(country))]
+	return sys, ok
+}
+
+// Normalize maps rating to its canonical spelling in the system, ignoring
+// case, spaces and hyphens, so "pg13" becomes "PG-13". A leading system name
+// is accepted too, as in "FSK 12".
+func (s System) Normalize(rating string) (string, bool) {
+	k := key(rating)
+	if trimmed := strings.TrimPrefix(k, key(s.Name)); trimmed != "" {
+		k = trimmed
+	}
+	if canonical, ok := s.aliases[k]; ok {
+		return canonical, true
+	}
+	for _, canonical := range s.Ratings {
+		if key(canonical) == k {
+			return canonical, true
+		}
+	}
+	return "", false
+}
+
+// NormalizeMPA maps rating to its canonical MPA spelling.
+func NormalizeMPA(rating string) (string, bool) {
+	return systems["US"].Normalize(rating)
+}
+
+func key(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(s)))
+}
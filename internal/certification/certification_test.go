@@ -0,0 +1,44 @@
+package certification
+
+import "testing"
+
+func TestNormalizeMPA(t *testing.T) {
+	cases := map[string]string{
+		"pg13":      "PG-13",
+		"PG-13 ":    "PG-13",
+		"nc 17":     "NC-17",
+		"Unrated":   "NR",
+		"Not Rated": "NR",
+		"g":         "G",
+	}
+	for in, want := range cases {
+		if got, ok := NormalizeMPA(in); !ok || got != want {
+			t.Fatalf("NormalizeMPA(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "PG-15", "X", "MPA"} {
+		if _, ok := NormalizeMPA(in); ok {
+			t.Fatalf("NormalizeMPA(%q) should fail", in)
+		}
+	}
+}
+
+func TestSystemNormalize(t *testing.T) {
+	gb, ok := Lookup("gb")
+	if !ok || gb.Name != "BBFC" {
+		t.Fatalf("Lookup(gb) = %+v, %v", gb, ok)
+	}
+	if got, ok := gb.Normalize("12a"); !ok || got != "12A" {
+		t.Fatalf("BBFC 12a = %q, %v", got, ok)
+	}
+	de, _ := Lookup("DE")
+	if got, ok := de.Normalize("FSK 16"); !ok || got != "16" {
+		t.Fatalf("FSK 16 = %q, %v", got, ok)
+	}
+	if _, ok := de.Normalize("15"); ok {
+		t.Fatalf("FSK has no 15 rating")
+	}
+	if _, ok := Lookup("FR"); ok {
+		t.Fatalf("FR has no registered system")
+	}
+}
@@ -23,6 +23,9 @@ type Config struct {
 	DBMaxLifeSecs        int
 	DBConnTimeoutSecs    int
 	DBStatementCache     int
+	TrashRetentionHours  int
+	TrashPurgeEveryMins  int
+	IdempotencyTTLHours  int
 }
 
 // Load reads configuration from environment variables, applying defaults and validation.
@@ -43,6 +46,9 @@ func Load() (Config, error) {
 		DBMaxLifeSecs:        getEnvInt("DB_MAX_CONN_LIFETIME_SECS", 3600),
 		DBConnTimeoutSecs:    getEnvInt("DB_CONN_TIMEOUT_SECS", 10),
 		DBStatementCache:     getEnvInt("DB_STATEMENT_CACHE_CAPACITY", 256),
+		TrashRetentionHours:  getEnvInt("TRASH_RETENTION_HOURS", 720),
+		TrashPurgeEveryMins:  getEnvInt("TRASH_PURGE_INTERVAL_MINS", 60),
+		IdempotencyTTLHours:  getEnvInt("IDEMPOTENCY_TTL_HOURS", 24),
 	}
 
 	if cfg.AuthToken == "" {
@@ -72,6 +78,15 @@ func Load() (Config, error) {
 	if cfg.DBStatementCache < 0 {
 		return Config{}, fmt.Errorf("DB_STATEMENT_CACHE_CAPACITY must be non-negative")
 	}
+	if cfg.TrashRetentionHours <= 0 {
+		return Config{}, fmt.Errorf("TRASH_RETENTION_HOURS must be positive")
+	}
+	if cfg.TrashPurgeEveryMins <= 0 {
+		return Config{}, fmt.Errorf("TRASH_PURGE_INTERVAL_MINS must be positive")
+	}
+	if cfg.IdempotencyTTLHours <= 0 {
+		return Config{}, fmt.Errorf("IDEMPOTENCY_TTL_HOURS must be positive")
+	}
 
 	return cfg, nil
 }
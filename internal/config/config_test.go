@@ -20,6 +20,7 @@ func TestLoadSuccess(t *testing.T) {
 	t.Setenv("DB_MAX_CONNS", "40")
 	t.Setenv("DB_MIN_CONNS", "5")
 	t.Setenv("DB_STATEMENT_CACHE_CAPACITY", "128")
+	t.Setenv("TRASH_RETENTION_HOURS", "48")
 
 	cfg, err := Load()
 	if err != nil {
@@ -41,6 +42,15 @@ func TestLoadSuccess(t *testing.T) {
 	if cfg.DBStatementCache != 128 {
 		t.Fatalf("DBStatementCache = %d, want 128", cfg.DBStatementCache)
 	}
+	if cfg.TrashRetentionHours != 48 {
+		t.Fatalf("TrashRetentionHours = %d, want 48", cfg.TrashRetentionHours)
+	}
+	if cfg.TrashPurgeEveryMins != 60 {
+		t.Fatalf("TrashPurgeEveryMins = %d, want 60", cfg.TrashPurgeEveryMins)
+	}
+	if cfg.IdempotencyTTLHours != 24 {
+		t.Fatalf("IdempotencyTTLHours = %d, want 24", cfg.IdempotencyTTLHours)
+	}
 }
 
 func TestLoadValidationErrors(t *testing.T) {
@@ -90,6 +100,22 @@ func TestLoadValidationErrors(t *testing.T) {
 			},
 			wantErr: "DB_STATEMENT_CACHE_CAPACITY",
 		},
+		{
+			name: "zero trash retention",
+			setup: func(t *testing.T) {
+				setRequiredEnvs(t)
+				t.Setenv("TRASH_RETENTION_HOURS", "0")
+			},
+			wantErr: "TRASH_RETENTION_HOURS",
+		},
+		{
+			name: "zero idempotency ttl",
+			setup: func(t *testing.T) {
+				setRequiredEnvs(t)
+				t.Setenv("IDEMPOTENCY_TTL_HOURS", "0")
+			},
+			wantErr: "IDEMPOTENCY_TTL_HOURS",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,13 @@
+package domain
+
+import "time"
+
+// Collection groups movies in a fixed order, e.g. a franchise or trilogy.
+type Collection struct {
+	ID          string
+	Name        string
+	Description *string
+	MovieCount  int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,9 @@
+package domain
+
+// Genre is a canonical genre of the managed taxonomy. Synonyms lists the
+// alternative spellings that resolve to it.
+type Genre struct {
+	ID       int
+	Name     string
+	Synonyms []string
+}
@@ -16,9 +16,57 @@ type BoxOffice struct {
 	LastUpdated time.Time `json:"lastUpdated"`
 }
 
+// TitleKind classifies an alternate title.
+type TitleKind string
+
+const (
+	// TitleKindOriginal is the title in the movie's original language.
+	TitleKindOriginal TitleKind = "original"
+	// TitleKindLocalized is a release title for a given language/region.
+	TitleKindLocalized TitleKind = "localized"
+	// TitleKindWorking is a pre-release or production title.
+	TitleKindWorking TitleKind = "working"
+)
+
+// AlternateTitle is another name a movie is known by.
+type AlternateTitle struct {
+	Title    string    `json:"title"`
+	Language *string   `json:"language"`
+	Region   *string   `json:"region"`
+	Kind     TitleKind `json:"kind"`
+}
+
+// Certification is a movie's age rating in one country's system, e.g. GB/BBFC/15.
+type Certification struct {
+	Country string `json:"country"`
+	System  string `json:"system"`
+	Rating  string `json:"rating"`
+}
+
+// ReleaseType classifies a regional release.
+type ReleaseType string
+
+const (
+	// ReleaseTypeTheatrical is a cinema release.
+	ReleaseTypeTheatrical ReleaseType = "theatrical"
+	// ReleaseTypeDigital is a streaming, download or home video release.
+	ReleaseTypeDigital ReleaseType = "digital"
+	// ReleaseTypeFestival is a festival premiere or screening.
+	ReleaseTypeFestival ReleaseType = "festival"
+)
+
+// Release is the date a movie came out in one country. Movie.ReleaseDate
+// stays the primary worldwide date; releases refine it per region.
+type Release struct {
+	Country string      `json:"country"`
+	Date    time.Time   `json:"date"`
+	Type    ReleaseType `json:"type"`
+}
+
 // Movie represents the canonical movie entity in the database/service.
 type Movie struct {
 	ID          string
+	Slug        string
 	Title       string
 	ReleaseDate time.Time
 	ReleaseYear int
@@ -29,4 +77,17 @@ type Movie struct {
 	BoxOffice   *BoxOffice
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	DeletedAt   *time.Time
+
+	RuntimeMinutes      *int
+	OriginalLanguage    *string
+	SpokenLanguages     []string
+	ProductionCountries []string
+	Synopsis            *string
+	PosterURL           *string
+
+	Genres          []string
+	AlternateTitles []AlternateTitle
+	Certifications  []Certification
+	Releases        []Release
 }
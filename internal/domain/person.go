@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Person is someone credited on movies, in front of or behind the camera.
+type Person struct {
+	ID        string
+	Name      string
+	BirthDate *time.Time
+	Biography *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CreditRole is the capacity in which a person worked on a movie.
+type CreditRole string
+
+// Supported credit roles.
+const (
+	CreditRoleDirector        CreditRole = "director"
+	CreditRoleWriter          CreditRole = "writer"
+	CreditRoleProducer        CreditRole = "producer"
+	CreditRoleActor           CreditRole = "actor"
+	CreditRoleComposer        CreditRole = "composer"
+	CreditRoleCinematographer CreditRole = "cinematographer"
+	CreditRoleEditor          CreditRole = "editor"
+)
+
+// CreditRoles lists every supported role.
+var CreditRoles = []CreditRole{
+	CreditRoleDirector, CreditRoleWriter, CreditRoleProducer, CreditRoleActor,
+	CreditRoleComposer, CreditRoleCinematographer, CreditRoleEditor,
+}
+
+// Credit links a person to a movie. Character is only set for actors.
+// BillingOrder ranks credits within a movie, lowest first. The person and
+// movie summary fields are filled in depending on which side is listed.
+type Credit struct {
+	ID           int64
+	MovieID      string
+	PersonID     string
+	Role         CreditRole
+	Character    *string
+	BillingOrder int
+
+	PersonName       string
+	MovieTitle       string
+	MovieSlug        string
+	MovieReleaseDate time.Time
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RevisionSource identifies what initiated a movie change.
+type RevisionSource string
+
+const (
+	// RevisionSourceUser marks changes made through the API by an editor.
+	RevisionSourceUser RevisionSource = "user"
+	// RevisionSourceBoxOffice marks changes applied by box office enrichment.
+	RevisionSourceBoxOffice RevisionSource = "boxoffice"
+)
+
+// MovieRevision is one entry of a movie's audit trail. Before/After only hold
+// the fields listed in ChangedFields; Before is null for the creating revision.
+type MovieRevision struct {
+	ID            int64
+	MovieID       string
+	Actor         string
+	Source        RevisionSource
+	ChangedFields []string
+	Before        json.RawMessage
+	After         json.RawMessage
+	CreatedAt     time.Time
+}
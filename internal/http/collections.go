@@ -0,0 +1,252 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+type collectionRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+type collectionMoviesRequest struct {
+	MovieIDs []string `json:"movieIds"`
+}
+
+type collectionResponse struct {
+	ID          string                   `json:"id"`
+	Name        string                   `json:"name"`
+	Description *string                  `json:"description,omitempty"`
+	MovieCount  int                      `json:"movieCount"`
+	Movies      []movieResponse          `json:"movies,omitempty"`
+	Rating      *ratingAggregateResponse `json:"rating,omitempty"`
+	UpdatedAt   time.Time                `json:"updatedAt"`
+}
+
+type collectionListResponse struct {
+	Items []collectionResponse `json:"items"`
+}
+
+func (s *Server) handleListCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := s.repo.Collections.List(r.Context())
+	if err != nil {
+		s.logger.Printf("list collections error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list collections")
+		return
+	}
+	items := make([]collectionResponse, 0, len(collections))
+	for _, collection := range collections {
+		items = append(items, toCollectionResponse(collection))
+	}
+	s.respondJSON(w, http.StatusOK, collectionListResponse{Items: items})
+}
+
+func (s *Server) handleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req collectionRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	collection, err := s.repo.Collections.Create(r.Context(), params)
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			s.respondError(w, http.StatusConflict, "CONFLICT", "A collection with this name already exists")
+			return
+		}
+		s.logger.Printf("create collection error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create collection")
+		return
+	}
+	w.Header().Set("Location", "/collections/"+collection.ID)
+	s.respondJSON(w, http.StatusCreated, toCollectionResponse(collection))
+}
+
+// handleGetCollection returns the collection with its live movies in
+// collection order and a rating aggregate pooled across them.
+func (s *Server) handleGetCollection(w http.ResponseWriter, r *http.Request) {
+	collection, ok := s.collectionFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	movies, err := s.repo.Collections.Movies(r.Context(), collection.ID)
+	if err != nil {
+		s.logger.Printf("fetch collection movies error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collection")
+		return
+	}
+	agg, err := s.repo.Ratings.AggregateForCollection(r.Context(), collection.ID)
+	if err != nil {
+		s.logger.Printf("aggregate collection ratings error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collection")
+		return
+	}
+
+	resp := toCollectionResponse(collection)
+	resp.Movies = make([]movieResponse, 0, len(movies))
+	for _, movie := range movies {
+		resp.Movies = append(resp.Movies, toMovieResponse(movie))
+	}
+	resp.Rating = &ratingAggregateResponse{Average: roundToOneDecimal(agg.Average), Count: agg.Count}
+	s.respondJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleReplaceCollection(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req collectionRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	collection, err := s.repo.Collections.Update(r.Context(), chi.URLParam(r, "id"), params)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		case errors.Is(err, repository.ErrConflict):
+			s.respondError(w, http.StatusConflict, "CONFLICT", "A collection with this name already exists")
+		default:
+			s.logger.Printf("update collection error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update collection")
+		}
+		return
+	}
+	s.respondJSON(w, http.StatusOK, toCollectionResponse(collection))
+}
+
+func (s *Server) handleDeleteCollection(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	if err := s.repo.Collections.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return
+		}
+		s.logger.Printf("delete collection error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete collection")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleReplaceCollectionMovies sets the ordered membership of a collection;
+// the order of movieIds is the collection order.
+func (s *Server) handleReplaceCollectionMovies(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req collectionMoviesRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	movieIDs, err := parseCollectionMovieIDs(req.MovieIDs)
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if err := s.repo.Collections.ReplaceMovies(r.Context(), id, movieIDs); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		case errors.Is(err, repository.ErrUnknownMovie), errors.Is(err, repository.ErrInvalidMovieIDs):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", strings.TrimPrefix(err.Error(), "repository: "))
+		case errors.Is(err, repository.ErrConstraint):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", constraintMessage(err))
+		default:
+			s.logger.Printf("replace collection movies error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update collection")
+		}
+		return
+	}
+	s.handleGetCollection(w, r)
+}
+
+// collectionFromPath loads the collection named by the {id} path parameter,
+// writing the error response itself on failure.
+func (s *Server) collectionFromPath(w http.ResponseWriter, r *http.Request) (domain.Collection, bool) {
+	collection, err := s.repo.Collections.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return domain.Collection{}, false
+		}
+		s.logger.Printf("get collection error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch collection")
+		return domain.Collection{}, false
+	}
+	return collection, true
+}
+
+// toParams validates the payload and normalizes it into repository parameters.
+func (req collectionRequest) toParams() (repository.CollectionParams, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return repository.CollectionParams{}, fmt.Errorf("name is required")
+	}
+	return repository.CollectionParams{Name: name, Description: normalizeStringPtr(req.Description)}, nil
+}
+
+func parseCollectionMovieIDs(ids []string) ([]string, error) {
+	movieIDs := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for i, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			return nil, fmt.Errorf("movieIds[%d] is empty", i)
+		}
+		if _, dup := seen[id]; dup {
+			return nil, fmt.Errorf("movieIds[%d] duplicates an earlier entry", i)
+		}
+		seen[id] = struct{}{}
+		movieIDs = append(movieIDs, id)
+	}
+	return movieIDs, nil
+}
+
+func toCollectionResponse(collection domain.Collection) collectionResponse {
+	return collectionResponse{
+		ID:          collection.ID,
+		Name:        collection.Name,
+		Description: collection.Description,
+		MovieCount:  collection.MovieCount,
+		UpdatedAt:   collection.UpdatedAt,
+	}
+}
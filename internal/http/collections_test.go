@@ -0,0 +1,22 @@
+package httpserver
+
+import "testing"
+
+func TestParseCollectionMovieIDs(t *testing.T) {
+	ids, err := parseCollectionMovieIDs([]string{" a ", "b"})
+	if err != nil {
+		t.Fatalf("parseCollectionMovieIDs: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	for name, input := range map[string][]string{
+		"empty id":  {"a", " "},
+		"duplicate": {"a", "b", "a"},
+	} {
+		if _, err := parseCollectionMovieIDs(input); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
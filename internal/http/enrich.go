@@ -0,0 +1,101 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Imports skip the synchronous box office lookup POST /movies performs and
+// hand the movies they write to an enrichQueue instead.
+const (
+	// enrichBatchSize is the number of movie IDs queued at a time.
+	enrichBatchSize = 50
+	// enrichQueueSize bounds the batches waiting for a worker; imports wait
+	// when it is full rather than piling up work.
+	enrichQueueSize = 16
+	// enrichWorkers is the number of batches enriched concurrently.
+	enrichWorkers = 2
+)
+
+// errEnrichQueueClosed is returned for batches queued after shutdown began.
+var errEnrichQueueClosed = errors.New("box office enrichment is shutting down")
+
+// enrichQueue runs box office enrichment in the background on a fixed set of
+// workers. It is owned by the Server and drained by Server.Shutdown.
+type enrichQueue struct {
+	mu      sync.RWMutex
+	closed  bool
+	batches chan []string
+	// ctx outlives the requests that queue work; it is cancelled when a
+	// shutdown runs out of time.
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newEnrichQueue(workers, size int, enrich func(ctx context.Context, id string)) *enrichQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := &enrichQueue{
+		batches: make(chan []string, size),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ids := range q.batches {
+				for _, id := range ids {
+					if q.ctx.Err() != nil {
+						break
+					}
+					enrich(q.ctx, id)
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(q.done)
+	}()
+	return q
+}
+
+// enqueue hands a batch of movie IDs to the workers, waiting while the queue
+// is full. It fails once the queue is closed or when ctx ends first.
+func (q *enrichQueue) enqueue(ctx context.Context, ids []string) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return errEnrichQueueClosed
+	}
+	select {
+	case q.batches <- ids:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close stops accepting batches and waits for the queued ones to be enriched.
+// When ctx ends first the remaining work is abandoned and ctx's error
+// returned.
+func (q *enrichQueue) close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.batches)
+	}
+	q.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		return ctx.Err()
+	}
+}
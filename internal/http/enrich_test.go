@@ -0,0 +1,72 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEnrichQueue(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		enriched []string
+	)
+	release := make(chan struct{})
+	q := newEnrichQueue(1, 1, func(ctx context.Context, id string) {
+		<-release
+		mu.Lock()
+		enriched = append(enriched, id)
+		mu.Unlock()
+	})
+
+	ctx := context.Background()
+	if err := q.enqueue(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.enqueue(ctx, []string{"c"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	// The worker holds the first batch and the queue holds the second, so a
+	// third waits until its context gives up.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := q.enqueue(short, []string{"d"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a full queue to block, got %v", err)
+	}
+
+	close(release)
+	if err := q.close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if len(enriched) != 3 {
+		t.Fatalf("close must drain queued batches, enriched %v", enriched)
+	}
+	if err := q.enqueue(ctx, []string{"e"}); !errors.Is(err, errEnrichQueueClosed) {
+		t.Fatalf("expected errEnrichQueueClosed, got %v", err)
+	}
+}
+
+func TestEnrichQueue_CloseTimeout(t *testing.T) {
+	started := make(chan struct{})
+	q := newEnrichQueue(1, 1, func(ctx context.Context, id string) {
+		close(started)
+		<-ctx.Done()
+	})
+	if err := q.enqueue(context.Background(), []string{"a"}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected close to give up with the context, got %v", err)
+	}
+	select {
+	case <-q.done:
+	case <-time.After(time.Second):
+		t.Fatalf("workers must stop once close gives up")
+	}
+}
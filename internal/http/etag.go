@@ -0,0 +1,79 @@
+package httpserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// movieETag derives a strong entity tag for the movie detail representation:
+// the movie's updated_at timestamp, which the database bumps on every write
+// to the row, plus the embedded rating aggregate, which changes without
+// touching the row.
+func movieETag(movie domain.Movie, rating domain.RatingAggregate) string {
+	return `"` + strconv.FormatInt(movie.UpdatedAt.UnixMicro(), 36) +
+		"." + strconv.FormatInt(rating.Count, 36) +
+		"." + strconv.FormatInt(int64(math.Round(float64(rating.Average)*10)), 36) + `"`
+}
+
+// etagMatches reports whether any tag in a comma-separated If-Match /
+// If-None-Match header equals current. Weak tags only match when weak is true.
+func etagMatches(header, current string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified answers a conditional read with 304 when If-None-Match matches
+// etag.
+func (s *Server) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	header := r.Header.Get("If-None-Match")
+	if header == "" || !etagMatches(header, etag, true) {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatchPrecondition evaluates If-Match for a write. It returns the
+// updated_at value the repository must still observe (nil when the client
+// sent no precondition), or false after writing an error response.
+func (s *Server) ifMatchPrecondition(w http.ResponseWriter, r *http.Request, movie domain.Movie) (*time.Time, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil, true
+	}
+	agg, err := s.repo.Ratings.Aggregate(r.Context(), movie.ID)
+	if err != nil {
+		s.logger.Printf("aggregate rating error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to evaluate precondition")
+		return nil, false
+	}
+	if !etagMatches(header, movieETag(movie, agg), false) {
+		s.respondError(w, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "Movie has been modified; refetch and retry")
+		return nil, false
+	}
+	expected := movie.UpdatedAt
+	return &expected, true
+}
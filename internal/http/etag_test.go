@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+func TestEtagMatches(t *testing.T) {
+	current := movieETag(domain.Movie{UpdatedAt: time.Date(2024, 1, 1, 12, 0, 0, 123000, time.UTC)}, domain.RatingAggregate{Average: 4.5, Count: 2})
+
+	cases := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{"exact", current, false, true},
+		{"list", `"other", ` + current, false, true},
+		{"wildcard", "*", false, true},
+		{"weak for strong comparison", "W/" + current, false, false},
+		{"weak for weak comparison", "W/" + current, true, true},
+		{"mismatch", `"other"`, true, false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.header, current, tt.weak); got != tt.want {
+				t.Fatalf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMovieETagTracksRating(t *testing.T) {
+	movie := domain.Movie{UpdatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	unrated := movieETag(movie, domain.RatingAggregate{})
+	rated := movieETag(movie, domain.RatingAggregate{Average: 4, Count: 1})
+	rerated := movieETag(movie, domain.RatingAggregate{Average: 4.5, Count: 1})
+	if unrated == rated || rated == rerated {
+		t.Fatalf("tags must change with the rating: %s %s %s", unrated, rated, rerated)
+	}
+	if again := movieETag(movie, domain.RatingAggregate{Average: 4, Count: 1}); again != rated {
+		t.Fatalf("tag is not stable: %s != %s", again, rated)
+	}
+}
@@ -0,0 +1,279 @@
+package httpserver
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+// exportFlushEvery is the number of rows written between flushes, so clients
+// see progress on long exports.
+const exportFlushEvery = 200
+
+// exportFormat selects how an export stream is encoded.
+type exportFormat string
+
+// Supported export formats.
+const (
+	// exportFormatNDJSON is one movie representation per line, as returned by
+	// GET /movies/{title}.
+	exportFormatNDJSON exportFormat = "ndjson"
+	// exportFormatCSV is a header row of exportCSVColumns followed by one
+	// movie per record.
+	exportFormatCSV exportFormat = "csv"
+)
+
+// exportCSVColumns are the columns of a CSV export. List cells hold values
+// separated by "|", as in CSV imports. The columns an import does not take
+// (see exportOnlyCSVColumns) are skipped when the file is imported again.
+var exportCSVColumns = []string{
+	"id", "slug", "title", "releaseDate", "genre", "genres", "distributor", "budget", "mpaRating",
+	"runtimeMinutes", "originalLanguage", "spokenLanguages", "productionCountries", "synopsis", "posterUrl",
+	"boxOfficeWorldwide", "boxOfficeOpeningWeekendUSA", "boxOfficeCurrency", "boxOfficeLastUpdated",
+	"ratingAverage", "ratingCount", "deletedAt",
+}
+
+// movieExporter encodes exported movies onto a response body.
+type movieExporter interface {
+	// begin writes anything that precedes the first movie.
+	begin() error
+	write(movie domain.Movie, agg domain.RatingAggregate) error
+	flush() error
+}
+
+func (s *Server) handleExportMovies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format, err := exportFormatFromRequest(r)
+	if err != nil {
+		s.respondError(w, http.StatusNotAcceptable, "NOT_ACCEPTABLE", err.Error())
+		return
+	}
+	filters, err := buildMovieFilters(query)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	if filters.Deleted != repository.DeletedExclude && !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Large exports outlast the server's write timeout.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	var exporter movieExporter
+	switch format {
+	case exportFormatCSV:
+		exporter = newCSVExporter(w)
+	default:
+		exporter = newNDJSONExporter(w)
+	}
+
+	// The status line is held back until the first row so a failing query
+	// can still be reported as a 500.
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", exportContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="movies.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+		return exporter.begin()
+	}
+	rows := 0
+	err = s.repo.Movies.Export(r.Context(), filters, func(movie domain.Movie, agg domain.RatingAggregate) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := exporter.write(movie, agg); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushEvery == 0 {
+			if err := exporter.flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = exporter.flush()
+	}
+	if err != nil {
+		s.logger.Printf("export movies error after %d rows: %v", rows, err)
+		if !started {
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export movies")
+			return
+		}
+		// Headers are already sent; abort the connection so clients see a
+		// truncated stream rather than a clean end of file.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportFormatFromRequest picks the format from ?format=, falling back to the
+// most preferred format in the Accept header and then NDJSON. It fails when
+// Accept refuses both formats with q=0.
+func exportFormatFromRequest(r *http.Request) (exportFormat, error) {
+	if val := strings.TrimSpace(r.URL.Query().Get("format")); val != "" {
+		switch format := exportFormat(strings.ToLower(val)); format {
+		case exportFormatNDJSON, exportFormatCSV:
+			return format, nil
+		default:
+			return "", fmt.Errorf("format must be ndjson or csv")
+		}
+	}
+	var (
+		best    exportFormat
+		bestQ   float64
+		refused = make(map[exportFormat]bool)
+	)
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var formats []exportFormat
+		switch mediaType {
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			formats = []exportFormat{exportFormatNDJSON}
+		case "text/csv":
+			formats = []exportFormat{exportFormatCSV}
+		case "*/*":
+			formats = []exportFormat{exportFormatNDJSON, exportFormatCSV}
+		default:
+			continue
+		}
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		for _, format := range formats {
+			if q == 0 {
+				refused[format] = true
+			} else if q > bestQ {
+				best, bestQ = format, q
+			}
+		}
+	}
+	switch {
+	case best != "" && !refused[best]:
+		return best, nil
+	case !refused[exportFormatNDJSON]:
+		return exportFormatNDJSON, nil
+	case !refused[exportFormatCSV]:
+		return exportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("Accept must allow application/x-ndjson or text/csv")
+	}
+}
+
+func exportContentType(format exportFormat) string {
+	if format == exportFormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONExporter(w io.Writer) *ndjsonExporter {
+	return &ndjsonExporter{enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonExporter) begin() error { return nil }
+
+func (e *ndjsonExporter) write(movie domain.Movie, agg domain.RatingAggregate) error {
+	resp := toMovieResponse(movie)
+	resp.Rating = &ratingAggregateResponse{
+		Average: roundToOneDecimal(agg.Average),
+		Count:   agg.Count,
+	}
+	return e.enc.Encode(resp)
+}
+
+func (e *ndjsonExporter) flush() error { return nil }
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) *csvExporter {
+	return &csvExporter{w: csv.NewWriter(w)}
+}
+
+func (e *csvExporter) begin() error { return e.w.Write(exportCSVColumns) }
+
+func (e *csvExporter) write(movie domain.Movie, agg domain.RatingAggregate) error {
+	return e.w.Write(exportCSVRecord(movie, agg))
+}
+
+func (e *csvExporter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// exportCSVRecord renders movie in the order of exportCSVColumns. Absent
+// values are empty cells.
+func exportCSVRecord(movie domain.Movie, agg domain.RatingAggregate) []string {
+	record := []string{
+		movie.ID,
+		movie.Slug,
+		movie.Title,
+		movie.ReleaseDate.Format("2006-01-02"),
+		movie.Genre,
+		strings.Join(movie.Genres, "|"),
+		stringOrEmpty(movie.Distributor),
+		int64Cell(movie.Budget),
+		stringOrEmpty(movie.MpaRating),
+		"",
+		stringOrEmpty(movie.OriginalLanguage),
+		strings.Join(movie.SpokenLanguages, "|"),
+		strings.Join(movie.ProductionCountries, "|"),
+		stringOrEmpty(movie.Synopsis),
+		stringOrEmpty(movie.PosterURL),
+		"", "", "", "",
+		strconv.FormatFloat(float64(roundToOneDecimal(agg.Average)), 'f', 1, 32),
+		strconv.FormatInt(agg.Count, 10),
+		"",
+	}
+	if movie.RuntimeMinutes != nil {
+		record[9] = strconv.Itoa(*movie.RuntimeMinutes)
+	}
+	if movie.BoxOffice != nil {
+		record[15] = strconv.FormatInt(movie.BoxOffice.Revenue.Worldwide, 10)
+		record[16] = int64Cell(movie.BoxOffice.Revenue.OpeningWeekendUS)
+		record[17] = movie.BoxOffice.Currency
+		record[18] = movie.BoxOffice.LastUpdated.UTC().Format(time.RFC3339)
+	}
+	if movie.DeletedAt != nil {
+		record[21] = movie.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+func int64Cell(ptr *int64) string {
+	if ptr == nil {
+		return ""
+	}
+	return strconv.FormatInt(*ptr, 10)
+}
@@ -0,0 +1,158 @@
+package httpserver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+func TestExportFormatFromRequest(t *testing.T) {
+	cases := []struct {
+		target string
+		accept string
+		want   exportFormat
+		ok     bool
+	}{
+		{target: "/movies:export", want: exportFormatNDJSON, ok: true},
+		{target: "/movies:export?format=CSV", want: exportFormatCSV, ok: true},
+		{target: "/movies:export?format=ndjson", accept: "text/csv", want: exportFormatNDJSON, ok: true},
+		{target: "/movies:export", accept: "text/csv; q=0.9, application/json", want: exportFormatCSV, ok: true},
+		{target: "/movies:export", accept: "application/x-ndjson", want: exportFormatNDJSON, ok: true},
+		{target: "/movies:export", accept: "application/x-ndjson;q=0.5, text/csv;q=0.8", want: exportFormatCSV, ok: true},
+		{target: "/movies:export", accept: "text/csv;q=0.2, */*;q=0.9", want: exportFormatNDJSON, ok: true},
+		{target: "/movies:export", accept: "application/x-ndjson;q=0", want: exportFormatCSV, ok: true},
+		{target: "/movies:export", accept: "*/*;q=0", ok: false},
+		{target: "/movies:export?format=parquet", ok: false},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		got, err := exportFormatFromRequest(req)
+		if (err == nil) != tc.ok {
+			t.Fatalf("%s (Accept %q): err = %v, want ok=%v", tc.target, tc.accept, err, tc.ok)
+		}
+		if tc.ok && got != tc.want {
+			t.Fatalf("%s (Accept %q) = %q, want %q", tc.target, tc.accept, got, tc.want)
+		}
+	}
+}
+
+func TestExportCSVRecord(t *testing.T) {
+	budget := int64(160000000)
+	runtime := 148
+	distributor := "Warner Bros."
+	movie := domain.Movie{
+		ID:              "m1",
+		Slug:            "inception-2010",
+		Title:           "Inception",
+		ReleaseDate:     time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+		Genre:           "Science Fiction",
+		Genres:          []string{"Science Fiction", "Thriller"},
+		Distributor:     &distributor,
+		Budget:          &budget,
+		RuntimeMinutes:  &runtime,
+		SpokenLanguages: []string{"en", "ja"},
+		BoxOffice: &domain.BoxOffice{
+			Revenue:     domain.Revenue{Worldwide: 829895144},
+			Currency:    "USD",
+			LastUpdated: time.Date(2025, 9, 23, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	record := exportCSVRecord(movie, domain.RatingAggregate{Average: 4.26, Count: 3})
+	if len(record) != len(exportCSVColumns) {
+		t.Fatalf("record has %d cells, want %d", len(record), len(exportCSVColumns))
+	}
+	cell := func(column string) string {
+		for i, name := range exportCSVColumns {
+			if name == column {
+				return record[i]
+			}
+		}
+		t.Fatalf("unknown column %s", column)
+		return ""
+	}
+	want := map[string]string{
+		"slug":                       "inception-2010",
+		"releaseDate":                "2010-07-16",
+		"genres":                     "Science Fiction|Thriller",
+		"budget":                     "160000000",
+		"mpaRating":                  "",
+		"runtimeMinutes":             "148",
+		"spokenLanguages":            "en|ja",
+		"boxOfficeWorldwide":         "829895144",
+		"boxOfficeOpeningWeekendUSA": "",
+		"boxOfficeLastUpdated":       "2025-09-23T12:00:00Z",
+		"ratingAverage":              "4.3",
+		"ratingCount":                "3",
+		"deletedAt":                  "",
+	}
+	for column, value := range want {
+		if got := cell(column); got != value {
+			t.Fatalf("%s = %q, want %q", column, got, value)
+		}
+	}
+}
+
+func TestExportCSVRoundTrip(t *testing.T) {
+	budget := int64(160000000)
+	runtime := 148
+	distributor := "Warner Bros."
+	deleted := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	movie := domain.Movie{
+		ID:              "m1",
+		Slug:            "inception-2010",
+		Title:           "Inception",
+		ReleaseDate:     time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+		Genre:           "Science Fiction",
+		Genres:          []string{"Science Fiction", "Thriller"},
+		Distributor:     &distributor,
+		Budget:          &budget,
+		RuntimeMinutes:  &runtime,
+		SpokenLanguages: []string{"en", "ja"},
+		BoxOffice:       &domain.BoxOffice{Revenue: domain.Revenue{Worldwide: 829895144}, Currency: "USD"},
+		DeletedAt:       &deleted,
+	}
+
+	var buf bytes.Buffer
+	exporter := newCSVExporter(&buf)
+	if err := exporter.begin(); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := exporter.write(movie, domain.RatingAggregate{Average: 4.5, Count: 2}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := exporter.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	rows, err := newCSVRowReader(&buf)
+	if err != nil {
+		t.Fatalf("exported header must be importable: %v", err)
+	}
+	row, err := rows.next()
+	if err != nil || row.err != nil {
+		t.Fatalf("read exported row: %v %v", err, row.err)
+	}
+	req := row.req
+	if req.Title != movie.Title || req.ReleaseDate != "2010-07-16" || req.Genre != movie.Genre || len(req.Genres) != 2 || req.Genres[1] != "Thriller" {
+		t.Fatalf("unexpected imported row: %+v", req)
+	}
+	if req.Distributor == nil || *req.Distributor != distributor || req.Budget == nil || *req.Budget != budget ||
+		req.RuntimeMinutes == nil || *req.RuntimeMinutes != runtime || len(req.SpokenLanguages) != 2 {
+		t.Fatalf("unexpected imported metadata: %+v", req)
+	}
+	if _, ok := row.cells["id"]; ok {
+		t.Fatalf("export-only columns must be skipped, got cells %v", row.cells)
+	}
+	if _, err := rows.next(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
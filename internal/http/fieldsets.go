@@ -0,0 +1,157 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+// movieCoreFields are the movie fields that are always loaded, so selecting
+// them costs nothing extra.
+var movieCoreFields = []string{"id", "slug", "title", "releaseDate", "genre", "deletedAt", "rating"}
+
+// movieOptionalFields are loaded only when selected.
+var movieOptionalFields = []repository.MovieField{
+	repository.MovieFieldDistributor,
+	repository.MovieFieldBudget,
+	repository.MovieFieldMpaRating,
+	repository.MovieFieldBoxOffice,
+	repository.MovieFieldRuntimeMinutes,
+	repository.MovieFieldOriginalLanguage,
+	repository.MovieFieldSpokenLanguages,
+	repository.MovieFieldProductionCountries,
+	repository.MovieFieldSynopsis,
+	repository.MovieFieldPosterURL,
+	repository.MovieFieldAlternateTitles,
+	repository.MovieFieldGenres,
+	repository.MovieFieldCertifications,
+	repository.MovieFieldReleases,
+}
+
+// movieFieldParams maps the lower-cased names accepted by the fields
+// parameter to their JSON name.
+var movieFieldParams = func() map[string]string {
+	params := make(map[string]string, len(movieCoreFields)+len(movieOptionalFields))
+	for _, name := range movieCoreFields {
+		params[strings.ToLower(name)] = name
+	}
+	for _, field := range movieOptionalFields {
+		params[strings.ToLower(string(field))] = string(field)
+	}
+	return params
+}()
+
+// movieIncludes are the related resources include= can embed.
+var movieIncludes = map[string]string{"rating": "rating", "credits": "credits"}
+
+// movieFieldSelection is the shape of movie list items requested through the
+// fields and include parameters.
+type movieFieldSelection struct {
+	// keys are the JSON fields to keep; nil keeps the full representation.
+	keys map[string]bool
+	// load lists the optional fields to read; nil reads every field.
+	load    []repository.MovieField
+	credits bool
+}
+
+// parseMovieFieldSelection reads fields= (comma-separated, repeatable) and
+// include=. The id is always returned; included resources are returned in
+// addition to the selected fields.
+func parseMovieFieldSelection(query url.Values) (movieFieldSelection, error) {
+	var selection movieFieldSelection
+	for _, val := range query["fields"] {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			key, ok := movieFieldParams[strings.ToLower(name)]
+			if !ok {
+				return movieFieldSelection{}, fmt.Errorf("invalid fields value %q", name)
+			}
+			if selection.keys == nil {
+				selection.keys = map[string]bool{"id": true}
+				selection.load = make([]repository.MovieField, 0)
+			}
+			if !selection.keys[key] && !isMovieCoreField(key) {
+				selection.load = append(selection.load, repository.MovieField(key))
+			}
+			selection.keys[key] = true
+		}
+	}
+	for _, val := range query["include"] {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			include, ok := movieIncludes[strings.ToLower(name)]
+			if !ok {
+				return movieFieldSelection{}, fmt.Errorf("invalid include value %q: use rating or credits", name)
+			}
+			if include == "credits" {
+				selection.credits = true
+			}
+			if selection.keys != nil {
+				selection.keys[include] = true
+			}
+		}
+	}
+	return selection, nil
+}
+
+func isMovieCoreField(name string) bool {
+	for _, core := range movieCoreFields {
+		if core == name {
+			return true
+		}
+	}
+	return false
+}
+
+// movieListItem is a movie in a list, trimmed to the requested fields and
+// carrying any included credits.
+type movieListItem struct {
+	movieResponse
+	keys    map[string]bool
+	credits []creditResponse
+}
+
+// MarshalJSON encodes the selected fields straight from the movie, leaving
+// out unset omitempty fields as encoding movieResponse does.
+func (item movieListItem) MarshalJSON() ([]byte, error) {
+	if item.keys == nil && item.credits == nil {
+		return json.Marshal(item.movieResponse)
+	}
+	fields := make(map[string]interface{}, len(item.keys)+1)
+	add := func(key string, value interface{}, set bool) {
+		if set && (item.keys == nil || item.keys[key]) {
+			fields[key] = value
+		}
+	}
+	m := item.movieResponse
+	add("id", m.ID, true)
+	add("slug", m.Slug, true)
+	add("title", m.Title, true)
+	add("releaseDate", m.ReleaseDate, true)
+	add("genre", m.Genre, true)
+	add("distributor", m.Distributor, m.Distributor != nil)
+	add("budget", m.Budget, m.Budget != nil)
+	add("mpaRating", m.MpaRating, m.MpaRating != nil)
+	add("boxOffice", m.BoxOffice, true)
+	add("rating", m.Rating, m.Rating != nil)
+	add("deletedAt", m.DeletedAt, m.DeletedAt != nil)
+	add("runtimeMinutes", m.RuntimeMinutes, m.RuntimeMinutes != nil)
+	add("originalLanguage", m.OriginalLanguage, m.OriginalLanguage != nil)
+	add("spokenLanguages", m.SpokenLanguages, true)
+	add("productionCountries", m.ProductionCountries, true)
+	add("synopsis", m.Synopsis, m.Synopsis != nil)
+	add("posterUrl", m.PosterURL, m.PosterURL != nil)
+	add("genres", m.Genres, true)
+	add("alternateTitles", m.AlternateTitles, true)
+	add("certifications", m.Certifications, true)
+	add("releases", m.Releases, true)
+	add("credits", item.credits, item.credits != nil)
+	return json.Marshal(fields)
+}
@@ -0,0 +1,103 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+func TestParseMovieFieldSelection(t *testing.T) {
+	values, _ := url.ParseQuery("fields=title,POSTERURL&fields=boxOffice,title&include=credits")
+	selection, err := parseMovieFieldSelection(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantKeys := map[string]bool{"id": true, "title": true, "posterUrl": true, "boxOffice": true, "credits": true}
+	if !reflect.DeepEqual(selection.keys, wantKeys) {
+		t.Fatalf("keys = %v, want %v", selection.keys, wantKeys)
+	}
+	wantLoad := []repository.MovieField{repository.MovieFieldPosterURL, repository.MovieFieldBoxOffice}
+	if !reflect.DeepEqual(selection.load, wantLoad) || !selection.credits {
+		t.Fatalf("load = %v credits = %v", selection.load, selection.credits)
+	}
+
+	values, _ = url.ParseQuery("fields=id")
+	if selection, err = parseMovieFieldSelection(values); err != nil || selection.load == nil || len(selection.load) != 0 {
+		t.Fatalf("core-only selection should load no optional field: %+v %v", selection, err)
+	}
+
+	values, _ = url.ParseQuery("include=rating")
+	if selection, err = parseMovieFieldSelection(values); err != nil || selection.keys != nil || selection.load != nil {
+		t.Fatalf("include alone should keep the full representation: %+v %v", selection, err)
+	}
+
+	for _, query := range []string{"fields=cast", "include=boxOffice"} {
+		values, _ = url.ParseQuery(query)
+		if _, err := parseMovieFieldSelection(values); err == nil {
+			t.Fatalf("expected error for %s", query)
+		}
+	}
+}
+
+func TestMovieListItemMarshal(t *testing.T) {
+	poster := "https://example.com/p.jpg"
+	item := movieListItem{
+		movieResponse: movieResponse{ID: "m1", Title: "Inception", Genre: "Action", PosterURL: &poster},
+		keys:          map[string]bool{"id": true, "title": true, "posterUrl": true, "credits": true},
+		credits:       []creditResponse{},
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	keys := make([]string, 0, len(got))
+	for key := range got {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"credits", "id", "posterUrl", "title"}) || string(got["credits"]) != "[]" {
+		t.Fatalf("unexpected sparse item: %s", data)
+	}
+
+	full, err := json.Marshal(movieListItem{movieResponse: item.movieResponse})
+	if err != nil {
+		t.Fatalf("marshal full: %v", err)
+	}
+	plain, _ := json.Marshal(item.movieResponse)
+	if string(full) != string(plain) {
+		t.Fatalf("full item should match the movie representation:\n%s\n%s", full, plain)
+	}
+
+	// Selecting every field must reproduce the full representation.
+	budget := int64(160000000)
+	rich := movieListItem{movieResponse: movieResponse{
+		ID: "m1", Slug: "inception-2010", Title: "Inception", Genre: "Action", PosterURL: &poster, Budget: &budget,
+		Rating: &ratingAggregateResponse{Average: 4.5, Count: 2}, Genres: []string{"Action"},
+	}, keys: map[string]bool{}}
+	for _, key := range movieFieldParams {
+		rich.keys[key] = true
+	}
+	var selected, expected map[string]interface{}
+	data, err = json.Marshal(rich)
+	if err != nil {
+		t.Fatalf("marshal all fields: %v", err)
+	}
+	plain, _ = json.Marshal(rich.movieResponse)
+	if err := json.Unmarshal(data, &selected); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(plain, &expected); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(selected, expected) {
+		t.Fatalf("selecting every field should match the movie representation:\n%s\n%s", data, plain)
+	}
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+type genreCreateRequest struct {
+	Name     string   `json:"name"`
+	Synonyms []string `json:"synonyms"`
+}
+
+type genreResponse struct {
+	ID       int      `json:"id"`
+	Name     string   `json:"name"`
+	Synonyms []string `json:"synonyms"`
+}
+
+type genreListResponse struct {
+	Items []genreResponse `json:"items"`
+}
+
+func (s *Server) handleListGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := s.repo.Genres.List(r.Context())
+	if err != nil {
+		s.logger.Printf("list genres error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list genres")
+		return
+	}
+
+	items := make([]genreResponse, 0, len(genres))
+	for _, genre := range genres {
+		items = append(items, genreResponse{ID: genre.ID, Name: genre.Name, Synonyms: genre.Synonyms})
+	}
+	s.respondJSON(w, http.StatusOK, genreListResponse{Items: items})
+}
+
+func (s *Server) handleCreateGenre(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req genreCreateRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if repository.GenreKey(name) == "" {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "name must contain letters or digits")
+		return
+	}
+	synonyms := make([]string, 0, len(req.Synonyms))
+	for _, synonym := range req.Synonyms {
+		if repository.GenreKey(synonym) == "" {
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "synonyms must contain letters or digits")
+			return
+		}
+		synonyms = append(synonyms, strings.TrimSpace(synonym))
+	}
+
+	genre, err := s.repo.Genres.Create(r.Context(), name, synonyms)
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			s.respondError(w, http.StatusConflict, "CONFLICT", "The name or a synonym already belongs to a genre")
+			return
+		}
+		s.logger.Printf("create genre error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create genre")
+		return
+	}
+	s.respondJSON(w, http.StatusCreated, genreResponse{ID: genre.ID, Name: genre.Name, Synonyms: genre.Synonyms})
+}
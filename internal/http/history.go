@@ -0,0 +1,94 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+type revisionResponse struct {
+	ID            int64           `json:"id"`
+	Actor         string          `json:"actor"`
+	Source        string          `json:"source"`
+	ChangedFields []string        `json:"changedFields"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+type revisionListResponse struct {
+	Items      []revisionResponse `json:"items"`
+	NextCursor *string            `json:"nextCursor,omitempty"`
+}
+
+func (s *Server) handleMovieHistory(w http.ResponseWriter, r *http.Request) {
+	filters, err := buildRevisionFilters(r.URL.Query())
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	// A soft-deleted movie keeps its audit trail, including the deletion itself.
+	movie, ok := s.resolveMovieFromPath(w, r, true)
+	if !ok {
+		return
+	}
+
+	result, err := s.repo.Revisions.ListByMovie(r.Context(), movie.ID, filters)
+	if err != nil {
+		s.logger.Printf("list movie revisions error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch history")
+		return
+	}
+
+	items := make([]revisionResponse, 0, len(result.Items))
+	for _, rev := range result.Items {
+		items = append(items, revisionResponse{
+			ID:            rev.ID,
+			Actor:         rev.Actor,
+			Source:        string(rev.Source),
+			ChangedFields: rev.ChangedFields,
+			Before:        rev.Before,
+			After:         rev.After,
+			CreatedAt:     rev.CreatedAt,
+		})
+	}
+	s.respondJSON(w, http.StatusOK, revisionListResponse{Items: items, NextCursor: result.NextCursor})
+}
+
+func buildRevisionFilters(query url.Values) (repository.RevisionListFilters, error) {
+	var filters repository.RevisionListFilters
+	if val := strings.TrimSpace(query.Get("limit")); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			return filters, fmt.Errorf("invalid limit value")
+		}
+		filters.Limit = limit
+	}
+	if val := strings.TrimSpace(query.Get("cursor")); val != "" {
+		cursor, err := repository.DecodeRevisionCursor(val)
+		if err != nil {
+			return filters, fmt.Errorf("invalid cursor")
+		}
+		filters.Cursor = cursor
+	}
+	return filters, nil
+}
+
+// withActor tags the request context with the editor identity recorded in the
+// movie audit trail. The shared bearer token does not identify an editor, so
+// X-Actor-Id is informational and only honoured on authenticated writes;
+// everything else is recorded under the repository's default actor.
+func (s *Server) withActor(r *http.Request) *http.Request {
+	actor := strings.TrimSpace(r.Header.Get("X-Actor-Id"))
+	if actor == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || !s.verifyBearer(r.Header.Get("Authorization")) {
+		return r
+	}
+	return r.WithContext(repository.WithActor(r.Context(), actor))
+}
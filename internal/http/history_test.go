@@ -0,0 +1,34 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/config"
+)
+
+func TestWithActor(t *testing.T) {
+	srv := &Server{cfg: config.Config{AuthToken: "secret"}}
+	request := func(method, auth string) *http.Request {
+		req := httptest.NewRequest(method, "/movies/Dune", nil)
+		req.Header.Set("X-Actor-Id", "editor-1")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		return req
+	}
+
+	if req := request(http.MethodPatch, "Bearer secret"); srv.withActor(req).Context() == req.Context() {
+		t.Fatalf("expected the actor header to be honoured on an authenticated write")
+	}
+	for _, req := range []*http.Request{
+		request(http.MethodPatch, ""),
+		request(http.MethodPatch, "Bearer wrong"),
+		request(http.MethodGet, "Bearer secret"),
+	} {
+		if srv.withActor(req).Context() != req.Context() {
+			t.Fatalf("%s with %q must not set the actor", req.Method, req.Header.Get("Authorization"))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// maxIdempotencyKeyLength bounds the Idempotency-Key header value.
+	maxIdempotencyKeyLength = 255
+	// idempotencyLease is how long a claimed key stays in progress without a
+	// stored response before a retry of the same request may take it over.
+	idempotencyLease = time.Minute
+)
+
+// idempotentHeaders are the response headers stored with, and replayed from,
+// an idempotent response.
+var idempotentHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyRecorder passes a response through while keeping a copy of it.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(p []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+// idempotent runs handle at most once per Idempotency-Key within scope. A
+// retry with the same key and request gets the stored response replayed; the
+// same key with a different request is rejected with 422, and a retry racing
+// the original with 409 until the original's lease runs out. Server errors are
+// not stored so they can be retried. Requests without the header go straight
+// to handle.
+func (s *Server) idempotent(w http.ResponseWriter, r *http.Request, scope string, handle http.HandlerFunc) {
+	key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+	if key == "" {
+		handle(w, r)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "Idempotency-Key must be at most 255 characters")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	hash := requestFingerprint(r, body)
+
+	ttl := time.Duration(s.cfg.IdempotencyTTLHours) * time.Hour
+	now := time.Now()
+	record, claimed, err := s.repo.Idempotency.Begin(r.Context(), scope, key, hash, now.Add(-ttl), now.Add(-idempotencyLease))
+	if err != nil {
+		s.logger.Printf("claim idempotency key error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process request")
+		return
+	}
+	if !claimed {
+		switch {
+		case record.RequestHash != hash:
+			s.respondError(w, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+		case record.StatusCode == 0:
+			w.Header().Set("Retry-After", "1")
+			s.respondError(w, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS", "A request with this Idempotency-Key is still being processed")
+		default:
+			for name, value := range record.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.StatusCode)
+			_, _ = w.Write(record.Body)
+		}
+		return
+	}
+
+	// The outcome is stored even if the client has gone away meanwhile.
+	ctx := context.WithoutCancel(r.Context())
+	rec := &idempotencyRecorder{ResponseWriter: w}
+	stored := false
+	defer func() {
+		if !stored {
+			if err := s.repo.Idempotency.Release(ctx, scope, key, record.ClaimedAt); err != nil {
+				s.logger.Printf("release idempotency key error: %v", err)
+			}
+		}
+	}()
+
+	handle(rec, r)
+	if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+		return
+	}
+	headers := make(map[string]string, len(idempotentHeaders))
+	for _, name := range idempotentHeaders {
+		if value := w.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	if err := s.repo.Idempotency.Complete(ctx, scope, key, record.ClaimedAt, rec.status, headers, rec.body.Bytes()); err != nil {
+		s.logger.Printf("store idempotent response error: %v", err)
+		return
+	}
+	stored = true
+}
+
+// requestFingerprint hashes what makes two requests the same: method, path,
+// query and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,753 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+// importFlushEvery is the number of row results written between flushes, so
+// clients see progress on long imports.
+const importFlushEvery = 100
+
+// importStreamError marks an upload that cannot be read, or cannot be read
+// past some point, as opposed to individual bad rows.
+type importStreamError struct{ err error }
+
+func (e importStreamError) Error() string { return e.err.Error() }
+func (e importStreamError) Unwrap() error { return e.err }
+
+// ImportFormat selects how an import stream is parsed.
+type ImportFormat string
+
+// Supported import formats.
+const (
+	// ImportFormatNDJSON is one POST /movies payload per line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+	// ImportFormatCSV is a header row naming the columns in importCSVColumns,
+	// followed by one movie per record.
+	ImportFormatCSV ImportFormat = "csv"
+)
+
+// ImportConflictMode decides what happens to a row whose title and release
+// year match a live movie.
+type ImportConflictMode string
+
+// Supported conflict modes.
+const (
+	// ImportConflictSkip leaves the existing movie untouched.
+	ImportConflictSkip ImportConflictMode = "skip"
+	// ImportConflictUpsert updates the existing movie from the row. NDJSON
+	// rows replace it, as PUT does; CSV rows are merged into it, as PATCH
+	// does, because CSV cannot express every field.
+	ImportConflictUpsert ImportConflictMode = "upsert"
+)
+
+// Import row statuses.
+const (
+	importStatusCreated = "created"
+	importStatusUpdated = "updated"
+	importStatusSkipped = "skipped"
+	importStatusError   = "error"
+)
+
+// ImportOptions tunes an import run. In a dry run rows are validated and
+// checked for conflicts but nothing is written. Enrich queues box office
+// enrichment for the movies the run writes.
+type ImportOptions struct {
+	OnConflict ImportConflictMode
+	DryRun     bool
+	Enrich     bool
+}
+
+// ImportRowResult reports the outcome of one input row. Line is the line of
+// the row in the uploaded file.
+type ImportRowResult struct {
+	Line   int    `json:"line"`
+	Status string `json:"status"`
+	Title  string `json:"title,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Slug   string `json:"slug,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportRowWriter receives the result of each row as soon as the row has been
+// processed, in input order. An error stops the import.
+type ImportRowWriter func(ImportRowResult) error
+
+// ImportSummary counts the outcomes of an import run. Error is set when the
+// stream stopped being readable after some rows were already reported.
+type ImportSummary struct {
+	DryRun     bool               `json:"dryRun"`
+	OnConflict ImportConflictMode `json:"onConflict"`
+	Total      int                `json:"total"`
+	Created    int                `json:"created"`
+	Updated    int                `json:"updated"`
+	Skipped    int                `json:"skipped"`
+	Failed     int                `json:"failed"`
+	Error      string             `json:"error,omitempty"`
+}
+
+// ImportReport is the document written for an import run: the summary
+// followed by every row's result. It is streamed by importReportWriter and
+// never held in memory by the server.
+type ImportReport struct {
+	ImportSummary
+	Rows []ImportRowResult `json:"rows"`
+}
+
+// importRow is one parsed input row; err is set when the row could not be
+// decoded.
+type importRow struct {
+	line int
+	req  movieCreateRequest
+	// cells holds the non-empty values of a CSV row by column, so an upsert
+	// can keep what the row does not carry. It is nil for NDJSON rows, which
+	// are whole movies.
+	cells map[string]string
+	err   error
+}
+
+func (s *Server) handleImportMovies(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	r = s.withActor(r)
+
+	format, err := importFormatFromRequest(r)
+	if err != nil {
+		s.respondError(w, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", err.Error())
+		return
+	}
+	opts, err := buildImportOptions(r.URL.Query())
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Large uploads outlast the server's timeouts, and results are written
+	// while the body is still being read.
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	_ = rc.EnableFullDuplex()
+	defer r.Body.Close()
+
+	// The status line is held back until the first row so an unreadable
+	// header or a failing lookup can still be reported as an error.
+	report := newImportReportWriter(w)
+	rows := 0
+	summary, err := s.ImportMovies(r.Context(), r.Body, format, opts, func(result ImportRowResult) error {
+		if rows == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+		}
+		if err := report.row(result); err != nil {
+			return err
+		}
+		rows++
+		if rows%importFlushEvery == 0 {
+			if err := report.flush(); err != nil {
+				return err
+			}
+			_ = rc.Flush()
+		}
+		return nil
+	})
+	var streamErr importStreamError
+	switch {
+	case err == nil:
+	case rows == 0 && errors.As(err, &streamErr):
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	case rows == 0:
+		s.logger.Printf("import movies error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to import movies")
+		return
+	case errors.As(err, &streamErr):
+		// The rows before the unreadable part stand; say where it stopped.
+		summary.Error = err.Error()
+	default:
+		s.logger.Printf("import movies error after %d rows: %v", rows, err)
+		// Headers are already sent; abort the connection so clients see a
+		// truncated report rather than a complete one.
+		panic(http.ErrAbortHandler)
+	}
+
+	if rows == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := report.finish(summary); err != nil {
+		s.logger.Printf("write import report error: %v", err)
+	}
+}
+
+// importFormatFromRequest picks the format from the Content-Type header.
+func importFormatFromRequest(r *http.Request) (ImportFormat, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("Content-Type must be application/x-ndjson or text/csv")
+	}
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return ImportFormatNDJSON, nil
+	case "text/csv":
+		return ImportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("Content-Type must be application/x-ndjson or text/csv")
+	}
+}
+
+func buildImportOptions(query map[string][]string) (ImportOptions, error) {
+	get := func(key string) string {
+		if vals := query[key]; len(vals) > 0 {
+			return strings.TrimSpace(vals[0])
+		}
+		return ""
+	}
+	opts := ImportOptions{OnConflict: ImportConflictSkip, Enrich: true}
+	if val := get("onConflict"); val != "" {
+		switch mode := ImportConflictMode(strings.ToLower(val)); mode {
+		case ImportConflictSkip, ImportConflictUpsert:
+			opts.OnConflict = mode
+		default:
+			return opts, fmt.Errorf("invalid onConflict value")
+		}
+	}
+	if val := get("dryRun"); val != "" {
+		dryRun, err := strconv.ParseBool(val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid dryRun value")
+		}
+		opts.DryRun = dryRun
+	}
+	if val := get("enrich"); val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid enrich value")
+		}
+		opts.Enrich = parsed
+	}
+	return opts, nil
+}
+
+// ImportMovies reads movies from src and writes them one row at a time, so a
+// bad row never blocks the others and the upload is never held in memory.
+// Each row's result is passed to emit once the row is done. With opts.Enrich
+// the written movies are queued for box office enrichment in batches as the
+// import goes. An importStreamError is returned when src cannot be read any
+// further; the summary then covers the rows emitted before it.
+func (s *Server) ImportMovies(ctx context.Context, src io.Reader, format ImportFormat, opts ImportOptions, emit ImportRowWriter) (ImportSummary, error) {
+	if opts.OnConflict == "" {
+		opts.OnConflict = ImportConflictSkip
+	}
+	summary := ImportSummary{DryRun: opts.DryRun, OnConflict: opts.OnConflict}
+
+	var rows importRowReader
+	switch format {
+	case ImportFormatNDJSON:
+		rows = newNDJSONRowReader(src)
+	case ImportFormatCSV:
+		reader, err := newCSVRowReader(src)
+		if err != nil {
+			return summary, importStreamError{err}
+		}
+		rows = reader
+	default:
+		return summary, importStreamError{fmt.Errorf("unsupported import format %q", format)}
+	}
+
+	run := importRun{opts: opts}
+	if opts.DryRun {
+		run.created = make(map[string]struct{})
+	}
+	var pending []string
+	enqueue := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := s.enrich.enqueue(ctx, pending); err != nil {
+			s.logger.Printf("import: box office enrichment skipped for %d movies: %v", len(pending), err)
+		}
+		pending = nil
+	}
+	defer enqueue()
+
+	for {
+		row, err := rows.next()
+		if err == io.EOF {
+			return summary, nil
+		}
+		if err != nil {
+			return summary, importStreamError{err}
+		}
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		result := s.importRow(ctx, row, &run)
+		summary.Total++
+		switch result.Status {
+		case importStatusCreated:
+			summary.Created++
+		case importStatusUpdated:
+			summary.Updated++
+		case importStatusSkipped:
+			summary.Skipped++
+		default:
+			summary.Failed++
+		}
+		if opts.Enrich && !opts.DryRun && result.ID != "" && (result.Status == importStatusCreated || result.Status == importStatusUpdated) {
+			if pending = append(pending, result.ID); len(pending) == enrichBatchSize {
+				enqueue()
+			}
+		}
+		if err := emit(result); err != nil {
+			return summary, err
+		}
+	}
+}
+
+// importRun is the state shared by the rows of one import.
+type importRun struct {
+	opts ImportOptions
+	// created holds the title/year keys of the rows a dry run has reported as
+	// created, so a later row with the same key is reported as the conflict
+	// the real run would hit. Real runs find those rows in the database.
+	created map[string]struct{}
+}
+
+// importTitleYearKey mirrors the unique index on lower(title) and release year.
+func importTitleYearKey(title string, year int) string {
+	return strings.ToLower(title) + "\x00" + strconv.Itoa(year)
+}
+
+func (s *Server) importRow(ctx context.Context, row importRow, run *importRun) ImportRowResult {
+	opts := run.opts
+	result := ImportRowResult{Line: row.line, Title: strings.TrimSpace(row.req.Title)}
+	fail := func(msg string) ImportRowResult {
+		result.Status = importStatusError
+		result.Error = msg
+		return result
+	}
+	if row.err != nil {
+		return fail(row.err.Error())
+	}
+	params, err := row.req.toParams()
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	key := importTitleYearKey(params.Title, params.ReleaseDate.Year())
+	if _, dup := run.created[key]; dup && opts.DryRun {
+		// Nothing was written for the earlier row, so the lookup below
+		// would miss it.
+		if opts.OnConflict == ImportConflictSkip {
+			result.Status = importStatusSkipped
+		} else {
+			result.Status = importStatusUpdated
+		}
+		return result
+	}
+
+	existing, err := s.repo.Movies.GetByTitleYear(ctx, params.Title, params.ReleaseDate.Year())
+	switch {
+	case err == nil && opts.OnConflict == ImportConflictSkip:
+		result.Status, result.ID, result.Slug = importStatusSkipped, existing.ID, existing.Slug
+		return result
+	case err == nil:
+		result.Status, result.ID, result.Slug = importStatusUpdated, existing.ID, existing.Slug
+		if row.cells != nil {
+			req, err := mergeCSVRow(existing, row.cells)
+			if err == nil {
+				params, err = req.toParams()
+			}
+			if err != nil {
+				return fail(err.Error())
+			}
+		}
+		if opts.DryRun {
+			return result
+		}
+		updated, err := s.repo.Movies.Update(ctx, existing.ID, repository.MovieUpdateParams{
+			Title:           params.Title,
+			ReleaseDate:     params.ReleaseDate,
+			Genre:           params.Genre,
+			Genres:          params.Genres,
+			Distributor:     params.Distributor,
+			Budget:          params.Budget,
+			MpaRating:       params.MpaRating,
+			Metadata:        params.Metadata,
+			AlternateTitles: params.AlternateTitles,
+			Certifications:  params.Certifications,
+			Releases:        params.Releases,
+		})
+		if err != nil {
+			return fail(s.importWriteError(err))
+		}
+		result.Slug = updated.Slug
+		return result
+	case !errors.Is(err, repository.ErrNotFound):
+		return fail(s.importWriteError(err))
+	}
+
+	result.Status = importStatusCreated
+	if opts.DryRun {
+		run.created[key] = struct{}{}
+		return result
+	}
+	movie, err := s.repo.Movies.Create(ctx, params)
+	if err != nil {
+		return fail(s.importWriteError(err))
+	}
+	result.ID, result.Slug = movie.ID, movie.Slug
+	return result
+}
+
+// importWriteError turns a repository error into a row message, logging the
+// unexpected ones.
+func (s *Server) importWriteError(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrUnknownGenre):
+		return unknownGenreMessage(err)
+	case repository.IsTitleYearConflict(err):
+		return "a movie with this title and release year already exists"
+	case errors.Is(err, repository.ErrConflict):
+		return conflictMessage(err)
+	case errors.Is(err, repository.ErrConstraint):
+		return constraintMessage(err)
+	default:
+		s.logger.Printf("import movie error: %v", err)
+		return "failed to write movie"
+	}
+}
+
+// enrichMovieByID fetches box office data for an imported movie; it is the
+// work done by the enrichment queue.
+func (s *Server) enrichMovieByID(ctx context.Context, id string) {
+	movie, err := s.repo.Movies.GetByID(ctx, id)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Printf("enrich movie %s: %v", id, err)
+		}
+		return
+	}
+	s.enrichMovieWithBoxOffice(ctx, movie)
+}
+
+// importRowReader yields the rows of an import stream one at a time. next
+// returns io.EOF after the last row; any other error means the rest of the
+// stream cannot be read.
+type importRowReader interface {
+	next() (importRow, error)
+}
+
+type ndjsonRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRowReader(src io.Reader) *ndjsonRowReader {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRequestBody)
+	return &ndjsonRowReader{scanner: scanner}
+}
+
+func (r *ndjsonRowReader) next() (importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := importRow{line: r.line}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&row.req); err != nil {
+			row.err = ndjsonRowError(err)
+		}
+		return row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return importRow{}, fmt.Errorf("line %d exceeds %d bytes", r.line+1, maxRequestBody)
+		}
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
+
+func ndjsonRowError(err error) error {
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		return fmt.Errorf("invalid value for field %s", typeError.Field)
+	}
+	if strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return fmt.Errorf("unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	return fmt.Errorf("malformed JSON")
+}
+
+// importCSVColumns are the columns a CSV import may use. List columns hold
+// values separated by "|". Nested fields (alternate titles, certifications,
+// releases) are only available through NDJSON.
+var importCSVColumns = map[string]func(*movieCreateRequest, string) error{
+	"title":       func(req *movieCreateRequest, v string) error { req.Title = v; return nil },
+	"releasedate": func(req *movieCreateRequest, v string) error { req.ReleaseDate = v; return nil },
+	"genre":       func(req *movieCreateRequest, v string) error { req.Genre = v; return nil },
+	"genres":      func(req *movieCreateRequest, v string) error { req.Genres = splitList(v); return nil },
+	"distributor": func(req *movieCreateRequest, v string) error { req.Distributor = &v; return nil },
+	"budget": func(req *movieCreateRequest, v string) error {
+		budget, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("budget must be an integer")
+		}
+		req.Budget = &budget
+		return nil
+	},
+	"mparating": func(req *movieCreateRequest, v string) error { req.MpaRating = &v; return nil },
+	"runtimeminutes": func(req *movieCreateRequest, v string) error {
+		runtime, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("runtimeMinutes must be an integer")
+		}
+		req.RuntimeMinutes = &runtime
+		return nil
+	},
+	"originallanguage":    func(req *movieCreateRequest, v string) error { req.OriginalLanguage = &v; return nil },
+	"spokenlanguages":     func(req *movieCreateRequest, v string) error { req.SpokenLanguages = splitList(v); return nil },
+	"productioncountries": func(req *movieCreateRequest, v string) error { req.ProductionCountries = splitList(v); return nil },
+	"synopsis":            func(req *movieCreateRequest, v string) error { req.Synopsis = &v; return nil },
+	"posterurl":           func(req *movieCreateRequest, v string) error { req.PosterURL = &v; return nil },
+}
+
+// exportOnlyCSVColumns are the CSV export columns that describe stored state
+// (id, slug, box office, ratings, deletion) rather than input. Imports skip
+// them, so an exported file can be imported as is.
+var exportOnlyCSVColumns = func() map[string]struct{} {
+	columns := make(map[string]struct{})
+	for _, name := range exportCSVColumns {
+		if key := strings.ToLower(name); importCSVColumns[key] == nil {
+			columns[key] = struct{}{}
+		}
+	}
+	return columns
+}()
+
+// errCSVRecordTooLarge is returned for a CSV record longer than
+// maxRequestBody, which would otherwise be buffered whole.
+var errCSVRecordTooLarge = fmt.Errorf("CSV record exceeds %d bytes", maxRequestBody)
+
+// recordLimiter fails reads once more than limit bytes have been consumed
+// since the last reset, bounding the memory a single CSV record can take.
+type recordLimiter struct {
+	r     io.Reader
+	limit int
+	n     int
+}
+
+func (l *recordLimiter) Read(p []byte) (int, error) {
+	if l.n >= l.limit {
+		return 0, errCSVRecordTooLarge
+	}
+	if rem := l.limit - l.n; len(p) > rem {
+		p = p[:rem]
+	}
+	n, err := l.r.Read(p)
+	l.n += n
+	return n, err
+}
+
+type csvRowReader struct {
+	reader  *csv.Reader
+	limiter *recordLimiter
+	keys    []string
+}
+
+// newCSVRowReader reads and checks the header row.
+func newCSVRowReader(src io.Reader) (*csvRowReader, error) {
+	// The CSV reader buffers ahead, so allow for that on top of the record.
+	limiter := &recordLimiter{r: src, limit: maxRequestBody + 64*1024}
+	reader := csv.NewReader(limiter)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("CSV header row is missing")
+		}
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	keys := make([]string, len(header))
+	for i, name := range header {
+		keys[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := importCSVColumns[keys[i]]; ok {
+			continue
+		}
+		if _, ok := exportOnlyCSVColumns[keys[i]]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", strings.TrimSpace(name))
+		}
+		keys[i] = ""
+	}
+	return &csvRowReader{reader: reader, limiter: limiter, keys: keys}, nil
+}
+
+func (r *csvRowReader) next() (importRow, error) {
+	r.limiter.n = 0
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return importRow{}, io.EOF
+	}
+	var parseErr *csv.ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return importRow{}, err
+	}
+	if parseErr != nil {
+		if errors.Is(parseErr.Err, errCSVRecordTooLarge) {
+			return importRow{}, fmt.Errorf("line %d: %w", parseErr.StartLine, errCSVRecordTooLarge)
+		}
+		return importRow{line: parseErr.StartLine, err: parseErr.Err}, nil
+	}
+	line, _ := r.reader.FieldPos(0)
+	row := importRow{line: line, cells: make(map[string]string, len(record))}
+	for i, value := range record {
+		if value = strings.TrimSpace(value); value == "" || r.keys[i] == "" {
+			continue
+		}
+		if err := importCSVColumns[r.keys[i]](&row.req, value); err != nil {
+			row.err = err
+			break
+		}
+		row.cells[r.keys[i]] = value
+	}
+	return row, nil
+}
+
+// mergeCSVRow overlays the non-empty cells of a CSV row on an existing movie.
+// Columns missing from the file, empty cells and the nested fields CSV cannot
+// express keep their stored values.
+func mergeCSVRow(movie domain.Movie, cells map[string]string) (movieCreateRequest, error) {
+	req, err := moviePatchRequest{}.mergeInto(movie)
+	if err != nil {
+		return req, err
+	}
+	if _, ok := cells["genres"]; ok {
+		if _, ok := cells["genre"]; !ok {
+			// The first listed genre becomes primary, as in a PATCH.
+			req.Genre = ""
+		}
+	}
+	for key, value := range cells {
+		if err := importCSVColumns[key](&req, value); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+func splitList(v string) []string {
+	parts := strings.Split(v, "|")
+	out := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// importReportWriter streams an ImportReport as JSON: each row result on its
+// own line as it arrives, then the summary fields once the run is over.
+type importReportWriter struct {
+	w    *bufio.Writer
+	rows int
+}
+
+func newImportReportWriter(w io.Writer) *importReportWriter {
+	return &importReportWriter{w: bufio.NewWriter(w)}
+}
+
+func (rw *importReportWriter) row(result ImportRowResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if rw.rows == 0 {
+		sep = "{\"rows\":[\n"
+	}
+	rw.rows++
+	if _, err := rw.w.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = rw.w.Write(data)
+	return err
+}
+
+func (rw *importReportWriter) flush() error { return rw.w.Flush() }
+
+// finish closes the rows array, appends the summary and flushes.
+func (rw *importReportWriter) finish(summary ImportSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	head := "\n],"
+	if rw.rows == 0 {
+		head = "{\"rows\":[],"
+	}
+	// data is a JSON object; splice its fields in after the rows.
+	if _, err := rw.w.WriteString(head); err != nil {
+		return err
+	}
+	if _, err := rw.w.Write(data[1:]); err != nil {
+		return err
+	}
+	if err := rw.w.WriteByte('\n'); err != nil {
+		return err
+	}
+	return rw.w.Flush()
+}
+
+// WriteImportReport runs an import with its report streamed to w, for the
+// CLI. The report has the same shape as the POST /movies:import response.
+func (s *Server) WriteImportReport(ctx context.Context, w io.Writer, src io.Reader, format ImportFormat, opts ImportOptions) (ImportSummary, error) {
+	report := newImportReportWriter(w)
+	summary, err := s.ImportMovies(ctx, src, format, opts, report.row)
+	var streamErr importStreamError
+	switch {
+	case err == nil:
+	case report.rows > 0 && errors.As(err, &streamErr):
+		summary.Error = err.Error()
+	default:
+		return summary, err
+	}
+	return summary, report.finish(summary)
+}
+
+// ImportFormatForPath guesses the format from a file extension, for the CLI.
+func ImportFormatForPath(path string) (ImportFormat, bool) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return ImportFormatCSV, true
+	case strings.HasSuffix(lower, ".ndjson"), strings.HasSuffix(lower, ".jsonl"):
+		return ImportFormatNDJSON, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,285 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// readAllRows drains an importRowReader.
+func readAllRows(t *testing.T, reader importRowReader) ([]importRow, error) {
+	t.Helper()
+	var rows []importRow
+	for {
+		row, err := reader.next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+func TestReadNDJSONRows(t *testing.T) {
+	input := `{"title":"Inception","genre":"Sci-Fi","releaseDate":"2010-07-16"}
+
+{"title":"Dune","genres":["Sci-Fi"],"releaseDate":"2021-10-22","budget":"lots"}
+{"title":"Heat","rating":5}
+not json
+`
+	rows, err := readAllRows(t, newNDJSONRowReader(strings.NewReader(input)))
+	if err != nil {
+		t.Fatalf("read NDJSON rows: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want 4 (blank lines skipped)", len(rows))
+	}
+	if rows[0].err != nil || rows[0].line != 1 || rows[0].req.Title != "Inception" {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].line != 3 || rows[1].err == nil || !strings.Contains(rows[1].err.Error(), "budget") {
+		t.Fatalf("expected budget type error on line 3, got %+v", rows[1])
+	}
+	if rows[2].err == nil || !strings.Contains(rows[2].err.Error(), "rating") {
+		t.Fatalf("expected unknown field error, got %+v", rows[2])
+	}
+	if rows[3].err == nil {
+		t.Fatalf("expected malformed JSON error")
+	}
+
+	// Rows before an unreadable line are still delivered.
+	long := `{"title":"Heat"}` + "\n" + strings.Repeat("x", maxRequestBody+1) + "\n"
+	rows, err = readAllRows(t, newNDJSONRowReader(strings.NewReader(long)))
+	if err == nil || !strings.Contains(err.Error(), "line 2") || len(rows) != 1 {
+		t.Fatalf("expected a line 2 error after one row, got %d rows, err %v", len(rows), err)
+	}
+}
+
+func TestReadCSVRows(t *testing.T) {
+	input := "Title,releaseDate,genres,budget,spokenLanguages\n" +
+		"Inception,2010-07-16,Sci-Fi|Thriller,160000000,en|ja\n" +
+		"Dune,2021-10-22,Sci-Fi,unknown,\n" +
+		"Heat,1995-12-15\n"
+	reader, err := newCSVRowReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("newCSVRowReader: %v", err)
+	}
+	rows, err := readAllRows(t, reader)
+	if err != nil {
+		t.Fatalf("read CSV rows: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+	first := rows[0]
+	if first.err != nil || first.line != 2 || first.req.Title != "Inception" || len(first.req.Genres) != 2 || *first.req.Budget != 160000000 {
+		t.Fatalf("unexpected first row: %+v", first)
+	}
+	if len(first.req.SpokenLanguages) != 2 || first.req.SpokenLanguages[1] != "ja" {
+		t.Fatalf("spokenLanguages = %v", first.req.SpokenLanguages)
+	}
+	if rows[1].err == nil || rows[1].line != 3 {
+		t.Fatalf("expected budget error on line 3, got %+v", rows[1])
+	}
+	if rows[2].err == nil || rows[2].line != 4 {
+		t.Fatalf("expected field count error on line 4, got %+v", rows[2])
+	}
+
+	if _, err := newCSVRowReader(strings.NewReader("title,rating\nHeat,5\n")); err == nil {
+		t.Fatalf("expected error for unknown column")
+	}
+
+	reader, err = newCSVRowReader(strings.NewReader("title,synopsis\nHeat,short\nDune," + strings.Repeat("x", 2*maxRequestBody) + "\n"))
+	if err != nil {
+		t.Fatalf("newCSVRowReader: %v", err)
+	}
+	rows, err = readAllRows(t, reader)
+	if !errors.Is(err, errCSVRecordTooLarge) || len(rows) != 1 {
+		t.Fatalf("expected a record size error after one row, got %d rows, err %v", len(rows), err)
+	}
+}
+
+func TestImportReportWriter(t *testing.T) {
+	var buf bytes.Buffer
+	rw := newImportReportWriter(&buf)
+	for _, result := range []ImportRowResult{
+		{Line: 2, Status: importStatusCreated, Title: "Heat", ID: "m1"},
+		{Line: 3, Status: importStatusError, Error: "releaseDate is required"},
+	} {
+		if err := rw.row(result); err != nil {
+			t.Fatalf("row: %v", err)
+		}
+	}
+	if err := rw.finish(ImportSummary{OnConflict: ImportConflictSkip, Total: 2, Created: 1, Failed: 1}); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	var report ImportReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("decode %s: %v", buf.String(), err)
+	}
+	if report.Total != 2 || report.Created != 1 || len(report.Rows) != 2 || report.Rows[1].Line != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	buf.Reset()
+	if err := newImportReportWriter(&buf).finish(ImportSummary{OnConflict: ImportConflictSkip}); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil || report.Rows == nil || len(report.Rows) != 0 {
+		t.Fatalf("empty report %s: %+v %v", buf.String(), report, err)
+	}
+}
+
+func TestMergeCSVRow(t *testing.T) {
+	distributor := "Warner"
+	movie := domain.Movie{
+		Title:           "Inception",
+		Genre:           "Science Fiction",
+		Genres:          []string{"Science Fiction", "Thriller"},
+		ReleaseDate:     time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+		Distributor:     &distributor,
+		AlternateTitles: []domain.AlternateTitle{{Title: "Origen", Kind: domain.TitleKindLocalized}},
+		Certifications:  []domain.Certification{{Country: "US", System: "MPA", Rating: "PG-13"}},
+	}
+	req, err := mergeCSVRow(movie, map[string]string{"title": "Inception", "genres": "Drama|Thriller", "budget": "160000000"})
+	if err != nil {
+		t.Fatalf("mergeCSVRow: %v", err)
+	}
+	if req.Budget == nil || *req.Budget != 160000000 {
+		t.Fatalf("budget = %v, want the row's value", req.Budget)
+	}
+	if req.Genre != "" || len(req.Genres) != 2 || req.Genres[0] != "Drama" {
+		t.Fatalf("genres = %q %v, want the row's list with its first entry primary", req.Genre, req.Genres)
+	}
+	if req.Distributor == nil || *req.Distributor != "Warner" || req.ReleaseDate != "2010-07-16" {
+		t.Fatalf("columns missing from the row must be kept: %+v", req)
+	}
+	if len(req.AlternateTitles) != 1 || len(req.Certifications) != 1 {
+		t.Fatalf("nested fields must be kept: %+v", req)
+	}
+
+	if _, err := mergeCSVRow(movie, map[string]string{"budget": "lots"}); err == nil {
+		t.Fatalf("expected error for an invalid cell")
+	}
+}
+
+func TestBuildImportOptions(t *testing.T) {
+	values, _ := url.ParseQuery("onConflict=UPSERT&dryRun=true&enrich=false")
+	opts, err := buildImportOptions(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.OnConflict != ImportConflictUpsert || !opts.DryRun || opts.Enrich {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+
+	opts, err = buildImportOptions(url.Values{})
+	if err != nil || opts.OnConflict != ImportConflictSkip || opts.DryRun || !opts.Enrich {
+		t.Fatalf("unexpected defaults: %+v err=%v", opts, err)
+	}
+
+	for _, raw := range []string{"onConflict=replace", "dryRun=maybe", "enrich=2"} {
+		values, _ = url.ParseQuery(raw)
+		if _, err := buildImportOptions(values); err == nil {
+			t.Fatalf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestHandleImportMovies(t *testing.T) {
+	srv := buildTestServer(t)
+
+	post := func(query, contentType, body string) ImportReport {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/movies:import?"+query, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+		}
+		var report ImportReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("decode report: %v", err)
+		}
+		return report
+	}
+
+	csvBody := "title,releaseDate,genre,distributor\n" +
+		"Inception,2010-07-16,Sci-Fi,Warner\n" +
+		"Bad Date,2010-13-40,Polka,\n"
+	report := post("dryRun=true", "text/csv", csvBody)
+	if !report.DryRun || report.Created != 1 || report.Failed != 1 || report.Rows[0].ID != "" {
+		t.Fatalf("unexpected dry run report: %+v", report)
+	}
+	if _, err := srv.repo.Movies.GetByTitle(context.Background(), "Inception"); err == nil {
+		t.Fatalf("dry run must not write")
+	}
+
+	// A dry run sees the conflict a repeated title and year would hit.
+	dupBody := csvBody + "INCEPTION,2010-01-01,Sci-Fi,\n"
+	report = post("dryRun=true", "text/csv", dupBody)
+	if report.Created != 1 || report.Skipped != 1 || report.Rows[2].Status != "skipped" {
+		t.Fatalf("expected the repeated row to be skipped, got %+v", report)
+	}
+	report = post("dryRun=true&onConflict=upsert", "text/csv", dupBody)
+	if report.Created != 1 || report.Updated != 1 || report.Rows[2].Status != "updated" {
+		t.Fatalf("expected the repeated row to be updated, got %+v", report)
+	}
+
+	report = post("enrich=false", "text/csv", csvBody)
+	if report.Created != 1 || report.Rows[0].Slug != "inception-2010" {
+		t.Fatalf("unexpected import report: %+v", report)
+	}
+
+	ndjson := `{"title":"inception","genre":"Sci-Fi","releaseDate":"2010-07-16","distributor":"Legendary"}` + "\n"
+	report = post("enrich=false", "application/x-ndjson", ndjson)
+	if report.Skipped != 1 {
+		t.Fatalf("expected skip on conflict, got %+v", report)
+	}
+	report = post("enrich=false&onConflict=upsert", "application/x-ndjson", ndjson)
+	if report.Updated != 1 {
+		t.Fatalf("expected upsert on conflict, got %+v", report)
+	}
+	movie, err := srv.repo.Movies.GetByTitle(context.Background(), "Inception")
+	if err != nil {
+		t.Fatalf("GetByTitle: %v", err)
+	}
+	if movie.Distributor == nil || *movie.Distributor != "Legendary" {
+		t.Fatalf("upsert should replace distributor, got %v", movie.Distributor)
+	}
+
+	// CSV upserts keep what the file does not carry.
+	report = post("enrich=false&onConflict=upsert", "text/csv", "title,releaseDate,budget\nInception,2010-07-16,160000000\n")
+	if report.Updated != 1 {
+		t.Fatalf("expected CSV upsert on conflict, got %+v", report)
+	}
+	if movie, err = srv.repo.Movies.GetByTitle(context.Background(), "Inception"); err != nil {
+		t.Fatalf("GetByTitle: %v", err)
+	}
+	if movie.Budget == nil || *movie.Budget != 160000000 || movie.Distributor == nil || *movie.Distributor != "Legendary" {
+		t.Fatalf("CSV upsert should set budget and keep distributor, got %v %v", movie.Budget, movie.Distributor)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/movies:import", strings.NewReader(ndjson))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	srv.router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415", rec.Code)
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/boxoffice"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/certification"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/iso"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
 )
 
@@ -34,28 +36,131 @@ type errorResponse struct {
 }
 
 type movieCreateRequest struct {
-	Title       string  `json:"title"`
-	Genre       string  `json:"genre"`
-	ReleaseDate string  `json:"releaseDate"`
-	Distributor *string `json:"distributor"`
-	Budget      *int64  `json:"budget"`
-	MpaRating   *string `json:"mpaRating"`
+	Title           string                  `json:"title"`
+	Genre           string                  `json:"genre"`
+	Genres          []string                `json:"genres"`
+	ReleaseDate     string                  `json:"releaseDate"`
+	Distributor     *string                 `json:"distributor"`
+	Budget          *int64                  `json:"budget"`
+	MpaRating       *string                 `json:"mpaRating"`
+	AlternateTitles []alternateTitlePayload `json:"alternateTitles"`
+	Certifications  []certificationPayload  `json:"certifications"`
+	Releases        []releasePayload        `json:"releases"`
+
+	RuntimeMinutes      *int     `json:"runtimeMinutes"`
+	OriginalLanguage    *string  `json:"originalLanguage"`
+	SpokenLanguages     []string `json:"spokenLanguages"`
+	ProductionCountries []string `json:"productionCountries"`
+	Synopsis            *string  `json:"synopsis"`
+	PosterURL           *string  `json:"posterUrl"`
+}
+
+// alternateTitlePayload is the wire form of an alternate title, shared by
+// requests and responses.
+type alternateTitlePayload struct {
+	Title    string  `json:"title"`
+	Language *string `json:"language,omitempty"`
+	Region   *string `json:"region,omitempty"`
+	Kind     string  `json:"kind"`
+}
+
+// certificationPayload is the wire form of a national certification. System
+// is derived from the country and optional on requests.
+type certificationPayload struct {
+	Country string `json:"country"`
+	System  string `json:"system,omitempty"`
+	Rating  string `json:"rating"`
+}
+
+// releasePayload is the wire form of a regional release, shared by requests
+// and responses.
+type releasePayload struct {
+	Country string `json:"country"`
+	Date    string `json:"date"`
+	Type    string `json:"type"`
+}
+
+// moviePatchRequest follows JSON merge-patch semantics: absent fields are left
+// untouched and explicit nulls clear optional fields.
+type moviePatchRequest struct {
+	Title       optionalField[string] `json:"title"`
+	Genre       optionalField[string] `json:"genre"`
+	ReleaseDate optionalField[string] `json:"releaseDate"`
+	Distributor optionalField[string] `json:"distributor"`
+	Budget      optionalField[int64]  `json:"budget"`
+	MpaRating   optionalField[string] `json:"mpaRating"`
+
+	Genres          optionalField[[]string]                `json:"genres"`
+	AlternateTitles optionalField[[]alternateTitlePayload] `json:"alternateTitles"`
+	Certifications  optionalField[[]certificationPayload]  `json:"certifications"`
+	Releases        optionalField[[]releasePayload]        `json:"releases"`
+
+	RuntimeMinutes      optionalField[int]      `json:"runtimeMinutes"`
+	OriginalLanguage    optionalField[string]   `json:"originalLanguage"`
+	SpokenLanguages     optionalField[[]string] `json:"spokenLanguages"`
+	ProductionCountries optionalField[[]string] `json:"productionCountries"`
+	Synopsis            optionalField[string]   `json:"synopsis"`
+	PosterURL           optionalField[string]   `json:"posterUrl"`
+}
+
+// optionalField distinguishes an absent JSON member from an explicit null.
+type optionalField[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *optionalField[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
 }
 
 type movieListResponse struct {
-	Items      []movieResponse `json:"items"`
+	Items      []movieListItem `json:"items"`
 	NextCursor *string         `json:"nextCursor,omitempty"`
+	// Total and Facets are only present when requested with total=true and
+	// facets=...; they count every matching movie, not just this page.
+	Total  *int64                           `json:"total,omitempty"`
+	Facets map[string][]facetBucketResponse `json:"facets,omitempty"`
+}
+
+type facetBucketResponse struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
 }
 
 type movieResponse struct {
-	ID          string             `json:"id"`
-	Title       string             `json:"title"`
-	ReleaseDate string             `json:"releaseDate"`
-	Genre       string             `json:"genre"`
-	Distributor *string            `json:"distributor,omitempty"`
-	Budget      *int64             `json:"budget,omitempty"`
-	MpaRating   *string            `json:"mpaRating,omitempty"`
-	BoxOffice   *boxOfficeResponse `json:"boxOffice"`
+	ID          string                   `json:"id"`
+	Slug        string                   `json:"slug"`
+	Title       string                   `json:"title"`
+	ReleaseDate string                   `json:"releaseDate"`
+	Genre       string                   `json:"genre"`
+	Distributor *string                  `json:"distributor,omitempty"`
+	Budget      *int64                   `json:"budget,omitempty"`
+	MpaRating   *string                  `json:"mpaRating,omitempty"`
+	BoxOffice   *boxOfficeResponse       `json:"boxOffice"`
+	Rating      *ratingAggregateResponse `json:"rating,omitempty"`
+	DeletedAt   *time.Time               `json:"deletedAt,omitempty"`
+
+	RuntimeMinutes      *int     `json:"runtimeMinutes,omitempty"`
+	OriginalLanguage    *string  `json:"originalLanguage,omitempty"`
+	SpokenLanguages     []string `json:"spokenLanguages"`
+	ProductionCountries []string `json:"productionCountries"`
+	Synopsis            *string  `json:"synopsis,omitempty"`
+	PosterURL           *string  `json:"posterUrl,omitempty"`
+
+	Genres          []string                `json:"genres"`
+	AlternateTitles []alternateTitlePayload `json:"alternateTitles"`
+	Certifications  []certificationPayload  `json:"certifications"`
+	Releases        []releasePayload        `json:"releases"`
 }
 
 type boxOfficeResponse struct {
@@ -92,17 +197,58 @@ func (s *Server) handleListMovies(w http.ResponseWriter, r *http.Request) {
 		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
 		return
 	}
+	facets, withTotal, err := parseFacetParams(query)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	selection, err := parseMovieFieldSelection(query)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+	filters.Fields = selection.load
+	if filters.Deleted != repository.DeletedExclude && !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
 
 	result, err := s.repo.Movies.List(r.Context(), filters)
 	if err != nil {
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "cursor does not match this listing")
+			return
+		}
 		s.logger.Printf("list movies error: %v", err)
 		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list movies")
 		return
 	}
 
-	items := make([]movieResponse, 0, len(result.Items))
+	var credits map[string][]domain.Credit
+	if selection.credits && len(result.Items) > 0 {
+		ids := make([]string, 0, len(result.Items))
+		for _, movie := range result.Items {
+			ids = append(ids, movie.ID)
+		}
+		if credits, err = s.repo.People.CreditsByMovies(r.Context(), ids); err != nil {
+			s.logger.Printf("fetch credits error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list movies")
+			return
+		}
+	}
+
+	items := make([]movieListItem, 0, len(result.Items))
 	for _, movie := range result.Items {
-		items = append(items, toMovieResponse(movie))
+		item := movieListItem{movieResponse: toMovieResponse(movie), keys: selection.keys}
+		agg := result.Ratings[movie.ID]
+		item.Rating = &ratingAggregateResponse{
+			Average: roundToOneDecimal(agg.Average),
+			Count:   agg.Count,
+		}
+		if selection.credits {
+			item.credits = toCreditListResponse(credits[movie.ID]).Items
+		}
+		items = append(items, item)
 	}
 
 	resp := movieListResponse{
@@ -111,37 +257,264 @@ func (s *Server) handleListMovies(w http.ResponseWriter, r *http.Request) {
 	if result.NextCursor != nil {
 		resp.NextCursor = result.NextCursor
 	}
+	if len(facets) > 0 || withTotal {
+		counts, err := s.repo.Movies.Facets(r.Context(), filters, facets)
+		if err != nil {
+			s.logger.Printf("count movie facets error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list movies")
+			return
+		}
+		if withTotal {
+			resp.Total = &counts.Total
+		}
+		if len(facets) > 0 {
+			resp.Facets = make(map[string][]facetBucketResponse, len(counts.Facets))
+			for facet, buckets := range counts.Facets {
+				items := make([]facetBucketResponse, 0, len(buckets))
+				for _, bucket := range buckets {
+					items = append(items, facetBucketResponse{Value: bucket.Value, Count: bucket.Count})
+				}
+				resp.Facets[string(facet)] = items
+			}
+		}
+	}
 	s.respondJSON(w, http.StatusOK, resp)
 }
 
+// movieFacetParams maps the lower-cased names accepted by the facets parameter.
+var movieFacetParams = map[string]repository.MovieFacet{
+	"genre":       repository.MovieFacetGenre,
+	"year":        repository.MovieFacetYear,
+	"distributor": repository.MovieFacetDistributor,
+	"mparating":   repository.MovieFacetMpaRating,
+}
+
+// parseFacetParams reads the opt-in facets (comma-separated, repeatable) and
+// total parameters of GET /movies.
+func parseFacetParams(query url.Values) ([]repository.MovieFacet, bool, error) {
+	var facets []repository.MovieFacet
+	for _, val := range query["facets"] {
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			facet, ok := movieFacetParams[strings.ToLower(name)]
+			if !ok {
+				return nil, false, fmt.Errorf("invalid facets value %q: use genre, year, distributor or mpaRating", name)
+			}
+			facets = append(facets, facet)
+		}
+	}
+	withTotal := false
+	if val := strings.TrimSpace(query.Get("total")); val != "" {
+		parsed, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid total value")
+		}
+		withTotal = parsed
+	}
+	return facets, withTotal, nil
+}
+
+// movieSortParams maps the lower-cased names accepted by the sort parameter.
+var movieSortParams = map[string]repository.MovieSort{
+	"created":     repository.MovieSortCreated,
+	"relevance":   repository.MovieSortRelevance,
+	"releasedate": repository.MovieSortReleaseDate,
+	"title":       repository.MovieSortTitle,
+	"budget":      repository.MovieSortBudget,
+	"revenue":     repository.MovieSortRevenue,
+	"rating":      repository.MovieSortRating,
+}
+
 func buildMovieFilters(query url.Values) (repository.MovieListFilters, error) {
 	var filters repository.MovieListFilters
 
 	if q := strings.TrimSpace(query.Get("q")); q != "" {
 		filters.Query = &q
 	}
-	if val := strings.TrimSpace(query.Get("year")); val != "" {
-		year, err := strconv.Atoi(val)
+	var err error
+	if filters.Year, err = parseYearBound(query, "year"); err != nil {
+		return filters, err
+	}
+	if filters.YearFrom, err = parseYearBound(query, "yearFrom"); err != nil {
+		return filters, err
+	}
+	if filters.YearTo, err = parseYearBound(query, "yearTo"); err != nil {
+		return filters, err
+	}
+	if filters.YearFrom != nil && filters.YearTo != nil && *filters.YearFrom > *filters.YearTo {
+		return filters, fmt.Errorf("yearFrom must not be greater than yearTo")
+	}
+	for _, val := range query["genre"] {
+		for _, genre := range strings.Split(val, ",") {
+			if genre = strings.TrimSpace(genre); genre != "" {
+				filters.Genres = append(filters.Genres, genre)
+			}
+		}
+	}
+	if val := strings.TrimSpace(query.Get("genreMatch")); val != "" {
+		switch match := repository.GenreMatch(val); match {
+		case repository.GenreMatchAny, repository.GenreMatchAll:
+			filters.GenreMatch = match
+		default:
+			return filters, fmt.Errorf("invalid genreMatch value")
+		}
+	}
+	if val := strings.TrimSpace(query.Get("person")); val != "" {
+		filters.Person = &val
+	}
+	if val := strings.TrimSpace(query.Get("role")); val != "" {
+		role, ok := parseCreditRole(val)
+		if !ok {
+			return filters, fmt.Errorf("invalid role value")
+		}
+		if filters.Person == nil {
+			return filters, fmt.Errorf("role requires person")
+		}
+		filters.PersonRole = &role
+	}
+	if val := strings.TrimSpace(query.Get("collection")); val != "" {
+		filters.Collection = &val
+	}
+	for _, val := range query["language"] {
+		for _, code := range strings.Split(val, ",") {
+			if code = strings.TrimSpace(code); code == "" {
+				continue
+			}
+			normalized, ok := iso.NormalizeLanguage(code)
+			if !ok {
+				return filters, fmt.Errorf("invalid language value")
+			}
+			filters.Languages = append(filters.Languages, normalized)
+		}
+	}
+	for _, val := range query["country"] {
+		for _, code := range strings.Split(val, ",") {
+			if code = strings.TrimSpace(code); code == "" {
+				continue
+			}
+			normalized, ok := iso.NormalizeCountry(code)
+			if !ok {
+				return filters, fmt.Errorf("invalid country value")
+			}
+			filters.Countries = append(filters.Countries, normalized)
+		}
+	}
+	for _, val := range query["releasedIn"] {
+		for _, code := range strings.Split(val, ",") {
+			if code = strings.TrimSpace(code); code == "" {
+				continue
+			}
+			normalized, ok := iso.NormalizeCountry(code)
+			if !ok {
+				return filters, fmt.Errorf("invalid releasedIn value")
+			}
+			filters.ReleasedIn = append(filters.ReleasedIn, normalized)
+		}
+	}
+	if val := strings.TrimSpace(query.Get("releasedAfter")); val != "" {
+		date, err := time.Parse("2006-01-02", val)
 		if err != nil {
-			return filters, fmt.Errorf("invalid year value")
+			return filters, fmt.Errorf("invalid releasedAfter value")
 		}
-		filters.Year = &year
+		filters.ReleasedAfter = &date
 	}
-	if val := strings.TrimSpace(query.Get("genre")); val != "" {
-		filters.Genre = &val
+	if val := strings.TrimSpace(query.Get("releasedBefore")); val != "" {
+		date, err := time.Parse("2006-01-02", val)
+		if err != nil {
+			return filters, fmt.Errorf("invalid releasedBefore value")
+		}
+		filters.ReleasedBefore = &date
+	}
+	if filters.ReleasedAfter != nil && filters.ReleasedBefore != nil && filters.ReleasedAfter.After(*filters.ReleasedBefore) {
+		return filters, fmt.Errorf("releasedAfter must not be later than releasedBefore")
 	}
 	if val := strings.TrimSpace(query.Get("distributor")); val != "" {
 		filters.Distributor = &val
 	}
-	if val := strings.TrimSpace(query.Get("budget")); val != "" {
-		budget, err := strconv.ParseInt(val, 10, 64)
-		if err != nil || budget < 0 {
-			return filters, fmt.Errorf("invalid budget value")
+	if filters.BudgetMin, err = parseAmountParam(query, "budgetMin"); err != nil {
+		return filters, err
+	}
+	if filters.BudgetMax, err = parseAmountParam(query, "budgetMax"); err != nil {
+		return filters, err
+	}
+	// budget is the original name of budgetMax.
+	if budget, err := parseAmountParam(query, "budget"); err != nil {
+		return filters, err
+	} else if budget != nil {
+		if filters.BudgetMax != nil {
+			return filters, fmt.Errorf("budget cannot be combined with budgetMax")
 		}
-		filters.BudgetLTE = &budget
+		filters.BudgetMax = budget
+	}
+	if filters.BudgetMin != nil && filters.BudgetMax != nil && *filters.BudgetMin > *filters.BudgetMax {
+		return filters, fmt.Errorf("budgetMin must not be greater than budgetMax")
+	}
+	if filters.RevenueMin, err = parseAmountParam(query, "revenueMin"); err != nil {
+		return filters, err
+	}
+	if filters.RevenueMax, err = parseAmountParam(query, "revenueMax"); err != nil {
+		return filters, err
+	}
+	if filters.RevenueMin != nil && filters.RevenueMax != nil && *filters.RevenueMin > *filters.RevenueMax {
+		return filters, fmt.Errorf("revenueMin must not be greater than revenueMax")
+	}
+	if val := strings.TrimSpace(query.Get("minRating")); val != "" {
+		rating, err := strconv.ParseFloat(val, 64)
+		if err != nil || math.IsNaN(rating) || rating < 0 || rating > 5 {
+			return filters, fmt.Errorf("invalid minRating value: expected a number between 0 and 5")
+		}
+		filters.MinRating = &rating
+	}
+	if filters.MinVotes, err = parseAmountParam(query, "minVotes"); err != nil {
+		return filters, err
 	}
 	if val := strings.TrimSpace(query.Get("mpaRating")); val != "" {
-		filters.MpaRating = &val
+		rating, ok := certification.NormalizeMPA(val)
+		if !ok {
+			return filters, fmt.Errorf("invalid mpaRating value")
+		}
+		filters.MpaRating = &rating
+	}
+	for _, val := range query["certification"] {
+		for _, raw := range strings.Split(val, ",") {
+			if raw = strings.TrimSpace(raw); raw == "" {
+				continue
+			}
+			cert, ok := parseCertificationFilter(raw)
+			if !ok {
+				return filters, fmt.Errorf("invalid certification value")
+			}
+			filters.Certifications = append(filters.Certifications, cert)
+		}
+	}
+	if val := strings.TrimSpace(query.Get("deleted")); val != "" {
+		switch deleted := repository.DeletedFilter(val); deleted {
+		case repository.DeletedOnly, repository.DeletedInclude:
+			filters.Deleted = deleted
+		default:
+			return filters, fmt.Errorf("invalid deleted value")
+		}
+	}
+	if val := strings.TrimSpace(query.Get("sort")); val != "" {
+		name, order, _ := strings.Cut(strings.ToLower(val), ":")
+		sort, ok := movieSortParams[name]
+		if !ok {
+			return filters, fmt.Errorf("invalid sort value")
+		}
+		if sort == repository.MovieSortRelevance && filters.Query == nil {
+			return filters, fmt.Errorf("sort=relevance requires q")
+		}
+		filters.Sort = sort
+		switch repository.SortOrder(order) {
+		case repository.SortOrderAsc, repository.SortOrderDesc:
+			filters.Order = repository.SortOrder(order)
+		default:
+			if strings.Contains(val, ":") {
+				return filters, fmt.Errorf("invalid sort direction, use asc or desc")
+			}
+		}
 	}
 	if val := strings.TrimSpace(query.Get("limit")); val != "" {
 		limit, err := strconv.Atoi(val)
@@ -155,59 +528,398 @@ func buildMovieFilters(query url.Values) (repository.MovieListFilters, error) {
 		if err != nil {
 			return filters, fmt.Errorf("invalid cursor")
 		}
+		fingerprint, err := repository.FilterFingerprint(filters)
+		if err != nil {
+			return filters, err
+		}
+		if cursor.Sort != filters.Sort || cursor.Filter != fingerprint {
+			return filters, fmt.Errorf("cursor does not match this listing")
+		}
 		filters.Cursor = cursor
 	}
 	return filters, nil
 }
 
+// parseYearBound parses the optional release year in the named parameter.
+func parseYearBound(query url.Values, name string) (*int, error) {
+	val := strings.TrimSpace(query.Get(name))
+	if val == "" {
+		return nil, nil
+	}
+	year, err := strconv.Atoi(val)
+	if err != nil || year < 1 || year > 9999 {
+		return nil, fmt.Errorf("invalid %s value: expected a year such as 2010", name)
+	}
+	return &year, nil
+}
+
+// parseAmountParam parses the optional non-negative whole amount in the named
+// parameter.
+func parseAmountParam(query url.Values, name string) (*int64, error) {
+	val := strings.TrimSpace(query.Get(name))
+	if val == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid %s value: expected a non-negative whole number", name)
+	}
+	return &amount, nil
+}
+
 func (s *Server) handleCreateMovie(w http.ResponseWriter, r *http.Request) {
 	if !s.verifyBearer(r.Header.Get("Authorization")) {
 		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
 		return
 	}
+	r = s.withActor(r)
+	s.idempotent(w, r, "POST /movies", s.createMovie)
+}
 
+// createMovie handles an authenticated POST /movies.
+func (s *Server) createMovie(w http.ResponseWriter, r *http.Request) {
 	var req movieCreateRequest
 	if err := decodeJSONBody(w, r, &req); err != nil {
 		s.respondDecodeError(w, err)
 		return
 	}
 
-	releaseDate, err := time.Parse("2006-01-02", req.ReleaseDate)
+	params, err := req.toParams()
 	if err != nil {
-		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "releaseDate must follow YYYY-MM-DD format")
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
 		return
 	}
-	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Genre) == "" {
-		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "title and genre are required")
+
+	movie, err := s.repo.Movies.Create(r.Context(), params)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUnknownGenre):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", unknownGenreMessage(err))
+		case repository.IsTitleYearConflict(err):
+			s.respondTitleConflict(w, r, params.Title, params.ReleaseDate.Year())
+		case errors.Is(err, repository.ErrConflict):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", conflictMessage(err))
+		case errors.Is(err, repository.ErrConstraint):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", constraintMessage(err))
+		default:
+			s.logger.Printf("create movie error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create movie")
+		}
 		return
 	}
+
+	enrichedMovie := s.enrichMovieWithBoxOffice(r.Context(), movie)
+
+	w.Header().Set("Location", movieLocation(enrichedMovie))
+	// A new movie has no ratings yet.
+	w.Header().Set("ETag", movieETag(enrichedMovie, domain.RatingAggregate{}))
+	s.respondJSON(w, http.StatusCreated, toMovieResponse(enrichedMovie))
+}
+
+// toParams validates the payload and normalizes it into repository parameters.
+func (req movieCreateRequest) toParams() (repository.MovieCreateParams, error) {
+	releaseDate, err := time.Parse("2006-01-02", req.ReleaseDate)
+	if err != nil {
+		return repository.MovieCreateParams{}, fmt.Errorf("releaseDate must follow YYYY-MM-DD format")
+	}
+	genres := make([]string, 0, len(req.Genres))
+	for _, genre := range req.Genres {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			genres = append(genres, genre)
+		}
+	}
+	if strings.TrimSpace(req.Title) == "" || (strings.TrimSpace(req.Genre) == "" && len(genres) == 0) {
+		return repository.MovieCreateParams{}, fmt.Errorf("title and genre are required")
+	}
 	if req.Budget != nil && *req.Budget < 0 {
-		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "budget must be non-negative")
-		return
+		return repository.MovieCreateParams{}, fmt.Errorf("budget must be non-negative")
+	}
+	titles, err := parseAlternateTitles(req.AlternateTitles)
+	if err != nil {
+		return repository.MovieCreateParams{}, err
+	}
+	metadata, err := req.metadata()
+	if err != nil {
+		return repository.MovieCreateParams{}, err
+	}
+	mpaRating := normalizeStringPtr(req.MpaRating)
+	if mpaRating != nil {
+		rating, ok := certification.NormalizeMPA(*mpaRating)
+		if !ok {
+			return repository.MovieCreateParams{}, fmt.Errorf("mpaRating must be one of %s", strings.Join(certification.MPARatings, ", "))
+		}
+		mpaRating = &rating
+	}
+	certs, err := parseCertifications(req.Certifications)
+	if err != nil {
+		return repository.MovieCreateParams{}, err
+	}
+	releases, err := parseReleases(req.Releases)
+	if err != nil {
+		return repository.MovieCreateParams{}, err
 	}
+	return repository.MovieCreateParams{
+		Title:           strings.TrimSpace(req.Title),
+		ReleaseDate:     releaseDate,
+		Genre:           strings.TrimSpace(req.Genre),
+		Genres:          genres,
+		Distributor:     normalizeStringPtr(req.Distributor),
+		Budget:          req.Budget,
+		MpaRating:       mpaRating,
+		Metadata:        metadata,
+		AlternateTitles: titles,
+		Certifications:  certs,
+		Releases:        releases,
+	}, nil
+}
 
-	movie, err := s.repo.Movies.Create(r.Context(), repository.MovieCreateParams{
-		Title:       strings.TrimSpace(req.Title),
-		ReleaseDate: releaseDate,
-		Genre:       strings.TrimSpace(req.Genre),
-		Distributor: normalizeStringPtr(req.Distributor),
-		Budget:      req.Budget,
-		MpaRating:   normalizeStringPtr(req.MpaRating),
-	})
+// metadata validates the descriptive fields: runtime must be positive and
+// language and country codes are checked against ISO 639-1 and ISO 3166-1.
+func (req movieCreateRequest) metadata() (repository.MovieMetadata, error) {
+	if req.RuntimeMinutes != nil && *req.RuntimeMinutes <= 0 {
+		return repository.MovieMetadata{}, fmt.Errorf("runtimeMinutes must be positive")
+	}
+	original, err := normalizeLanguagePtr(req.OriginalLanguage, "originalLanguage")
 	if err != nil {
-		s.logger.Printf("create movie error: %v", err)
-		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create movie")
-		return
+		return repository.MovieMetadata{}, err
+	}
+	spoken, err := normalizeCodes(req.SpokenLanguages, "spokenLanguages", "ISO 639-1 language", iso.NormalizeLanguage)
+	if err != nil {
+		return repository.MovieMetadata{}, err
+	}
+	countries, err := normalizeCodes(req.ProductionCountries, "productionCountries", "ISO 3166-1 alpha-2 country", iso.NormalizeCountry)
+	if err != nil {
+		return repository.MovieMetadata{}, err
+	}
+	posterURL, err := validatePosterURL(req.PosterURL)
+	if err != nil {
+		return repository.MovieMetadata{}, err
 	}
+	return repository.MovieMetadata{
+		RuntimeMinutes:      req.RuntimeMinutes,
+		OriginalLanguage:    original,
+		SpokenLanguages:     spoken,
+		ProductionCountries: countries,
+		Synopsis:            normalizeStringPtr(req.Synopsis),
+		PosterURL:           posterURL,
+	}, nil
+}
+
+// parseAlternateTitles validates alternate titles and normalizes language
+// codes to lower case and region codes to upper case.
+func parseAlternateTitles(payload []alternateTitlePayload) ([]domain.AlternateTitle, error) {
+	titles := make([]domain.AlternateTitle, 0, len(payload))
+	seen := make(map[string]struct{}, len(payload))
+	for i, item := range payload {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			return nil, fmt.Errorf("alternateTitles[%d].title is required", i)
+		}
+		kind := domain.TitleKind(strings.ToLower(strings.TrimSpace(item.Kind)))
+		switch kind {
+		case domain.TitleKindOriginal, domain.TitleKindLocalized, domain.TitleKindWorking:
+		default:
+			return nil, fmt.Errorf("alternateTitles[%d].kind must be one of original, localized, working", i)
+		}
+		language, err := normalizeLanguagePtr(item.Language, fmt.Sprintf("alternateTitles[%d].language", i))
+		if err != nil {
+			return nil, err
+		}
+		region := normalizeStringPtr(item.Region)
+		if region != nil {
+			code, ok := iso.NormalizeCountry(*region)
+			if !ok {
+				return nil, fmt.Errorf("alternateTitles[%d].region must be an ISO 3166-1 alpha-2 country code", i)
+			}
+			region = &code
+		}
 
-	enrichedMovie := s.enrichMovieWithBoxOffice(r.Context(), movie, req)
+		key := strings.ToLower(title) + "|" + stringOrEmpty(language) + "|" + stringOrEmpty(region)
+		if _, dup := seen[key]; dup {
+			return nil, fmt.Errorf("alternateTitles[%d] duplicates an earlier entry", i)
+		}
+		seen[key] = struct{}{}
 
-	location := fmt.Sprintf("/movies/%s", url.PathEscape(enrichedMovie.Title))
-	w.Header().Set("Location", location)
-	s.respondJSON(w, http.StatusCreated, toMovieResponse(enrichedMovie))
+		titles = append(titles, domain.AlternateTitle{Title: title, Language: language, Region: region, Kind: kind})
+	}
+	return titles, nil
+}
+
+// parseCertifications validates each rating against its country's system. The
+// US rating is the movie's mpaRating and cannot be given here.
+func parseCertifications(payload []certificationPayload) ([]domain.Certification, error) {
+	certs := make([]domain.Certification, 0, len(payload))
+	seen := make(map[string]struct{}, len(payload))
+	for i, item := range payload {
+		country, ok := iso.NormalizeCountry(item.Country)
+		if !ok {
+			return nil, fmt.Errorf("certifications[%d].country must be an ISO 3166-1 alpha-2 country code", i)
+		}
+		if country == "US" {
+			return nil, fmt.Errorf("certifications[%d]: use mpaRating for US ratings", i)
+		}
+		sys, ok := certification.Lookup(country)
+		if !ok {
+			return nil, fmt.Errorf("certifications[%d].country %s has no supported certification system", i, country)
+		}
+		if system := strings.TrimSpace(item.System); system != "" && !strings.EqualFold(system, sys.Name) {
+			return nil, fmt.Errorf("certifications[%d].system must be %s for %s", i, sys.Name, country)
+		}
+		rating, ok := sys.Normalize(item.Rating)
+		if !ok {
+			return nil, fmt.Errorf("certifications[%d].rating must be one of %s", i, strings.Join(sys.Ratings, ", "))
+		}
+		if _, dup := seen[country]; dup {
+			return nil, fmt.Errorf("certifications[%d] repeats country %s", i, country)
+		}
+		seen[country] = struct{}{}
+		certs = append(certs, domain.Certification{Country: country, System: sys.Name, Rating: rating})
+	}
+	return certs, nil
+}
+
+// parseReleases validates regional releases; each country may have one release
+// per type.
+func parseReleases(payload []releasePayload) ([]domain.Release, error) {
+	releases := make([]domain.Release, 0, len(payload))
+	seen := make(map[string]struct{}, len(payload))
+	for i, item := range payload {
+		country, ok := iso.NormalizeCountry(item.Country)
+		if !ok {
+			return nil, fmt.Errorf("releases[%d].country must be an ISO 3166-1 alpha-2 country code", i)
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(item.Date))
+		if err != nil {
+			return nil, fmt.Errorf("releases[%d].date must follow YYYY-MM-DD format", i)
+		}
+		releaseType := domain.ReleaseType(strings.ToLower(strings.TrimSpace(item.Type)))
+		switch releaseType {
+		case domain.ReleaseTypeTheatrical, domain.ReleaseTypeDigital, domain.ReleaseTypeFestival:
+		default:
+			return nil, fmt.Errorf("releases[%d].type must be one of theatrical, digital, festival", i)
+		}
+		key := country + "|" + string(releaseType)
+		if _, dup := seen[key]; dup {
+			return nil, fmt.Errorf("releases[%d] repeats the %s release in %s", i, releaseType, country)
+		}
+		seen[key] = struct{}{}
+		releases = append(releases, domain.Release{Country: country, Date: date, Type: releaseType})
+	}
+	return releases, nil
+}
+
+func toReleasePayloads(releases []domain.Release) []releasePayload {
+	payload := make([]releasePayload, 0, len(releases))
+	for _, release := range releases {
+		payload = append(payload, releasePayload{
+			Country: release.Country,
+			Date:    release.Date.Format("2006-01-02"),
+			Type:    string(release.Type),
+		})
+	}
+	return payload
+}
+
+// parseCertificationFilter parses a "<country>:<rating>" filter value such as
+// "GB:15" into its canonical form.
+func parseCertificationFilter(raw string) (domain.Certification, bool) {
+	country, rating, ok := strings.Cut(raw, ":")
+	if !ok {
+		return domain.Certification{}, false
+	}
+	sys, ok := certification.Lookup(country)
+	if !ok {
+		return domain.Certification{}, false
+	}
+	rating, ok = sys.Normalize(rating)
+	if !ok {
+		return domain.Certification{}, false
+	}
+	return domain.Certification{Country: sys.Country, System: sys.Name, Rating: rating}, true
+}
+
+func toCertificationPayloads(certs []domain.Certification) []certificationPayload {
+	payload := make([]certificationPayload, 0, len(certs))
+	for _, cert := range certs {
+		payload = append(payload, certificationPayload{Country: cert.Country, System: cert.System, Rating: cert.Rating})
+	}
+	return payload
+}
+
+// unknownGenreMessage strips the repository prefix from an ErrUnknownGenre.
+func unknownGenreMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), "repository: ")
+}
+
+// normalizeLanguagePtr validates an optional ISO 639-1 code, naming field in
+// the error.
+func normalizeLanguagePtr(ptr *string, field string) (*string, error) {
+	ptr = normalizeStringPtr(ptr)
+	if ptr == nil {
+		return nil, nil
+	}
+	code, ok := iso.NormalizeLanguage(*ptr)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an ISO 639-1 language code", field)
+	}
+	return &code, nil
+}
+
+// normalizeCodes validates a list of ISO codes with normalize, dropping
+// duplicates and naming field in the error.
+func normalizeCodes(codes []string, field, standard string, normalize func(string) (string, bool)) ([]string, error) {
+	out := make([]string, 0, len(codes))
+	seen := make(map[string]struct{}, len(codes))
+	for i, raw := range codes {
+		code, ok := normalize(raw)
+		if !ok {
+			return nil, fmt.Errorf("%s[%d] must be an %s code", field, i, standard)
+		}
+		if _, dup := seen[code]; dup {
+			continue
+		}
+		seen[code] = struct{}{}
+		out = append(out, code)
+	}
+	return out, nil
+}
+
+// validatePosterURL requires an absolute http(s) URL.
+func validatePosterURL(ptr *string) (*string, error) {
+	ptr = normalizeStringPtr(ptr)
+	if ptr == nil {
+		return nil, nil
+	}
+	u, err := url.Parse(*ptr)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("posterUrl must be an absolute http(s) URL")
+	}
+	return ptr, nil
+}
+
+func stringOrEmpty(ptr *string) string {
+	if ptr == nil {
+		return ""
+	}
+	return *ptr
+}
+
+func toAlternateTitlePayloads(titles []domain.AlternateTitle) []alternateTitlePayload {
+	payload := make([]alternateTitlePayload, 0, len(titles))
+	for _, title := range titles {
+		payload = append(payload, alternateTitlePayload{
+			Title:    title.Title,
+			Language: title.Language,
+			Region:   title.Region,
+			Kind:     string(title.Kind),
+		})
+	}
+	return payload
 }
 
-func (s *Server) enrichMovieWithBoxOffice(ctx context.Context, movie domain.Movie, req movieCreateRequest) domain.Movie {
+// enrichMovieWithBoxOffice fills in box office data for movie. Values already
+// stored on the movie take precedence over upstream ones.
+func (s *Server) enrichMovieWithBoxOffice(ctx context.Context, movie domain.Movie) domain.Movie {
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.BoxOfficeTimeoutSecs)*time.Second)
 	defer cancel()
 
@@ -219,11 +931,11 @@ func (s *Server) enrichMovieWithBoxOffice(ctx context.Context, movie domain.Movi
 		return movie
 	}
 
-	distributor := firstNonNil(normalizeStringPtr(req.Distributor), result.Distributor)
-	budget := firstNonNilInt(req.Budget, result.Budget)
-	mpa := firstNonNil(normalizeStringPtr(req.MpaRating), result.MpaRating)
+	distributor := firstNonNil(movie.Distributor, result.Distributor)
+	budget := firstNonNilInt(movie.Budget, result.Budget)
+	mpa := firstNonNil(movie.MpaRating, result.MpaRating)
 
-	updated, err := s.repo.Movies.UpdateMetadata(ctx, movie.ID, distributor, budget, mpa, result.BoxOffice)
+	updated, err := s.repo.Movies.UpdateMetadata(ctx, movie.ID, distributor, budget, mpa, result.BoxOffice, result.Releases)
 	if err != nil {
 		s.logger.Printf("update movie metadata failed: %v", err)
 		return movie
@@ -231,27 +943,550 @@ func (s *Server) enrichMovieWithBoxOffice(ctx context.Context, movie domain.Movi
 	return updated
 }
 
-func (s *Server) handleSubmitRating(w http.ResponseWriter, r *http.Request) {
+func (s *Server) handleGetMovie(w http.ResponseWriter, r *http.Request) {
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+	s.respondMovieDetail(w, r, movie)
+}
+
+func (s *Server) handleGetMovieByID(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(chi.URLParam(r, "id"))
+	if id == "" {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "missing id parameter")
+		return
+	}
+
+	movie, err := s.repo.Movies.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return
+		}
+		s.logger.Printf("get movie by id error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie")
+		return
+	}
+	s.respondMovieDetail(w, r, movie)
+}
+
+func (s *Server) handleReplaceMovie(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	r = s.withActor(r)
+
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := s.ifMatchPrecondition(w, r, movie)
+	if !ok {
+		return
+	}
+
+	var req movieCreateRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	s.applyMovieUpdate(w, r, movie, req, expected)
+}
+
+func (s *Server) handlePatchMovie(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	r = s.withActor(r)
+
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := s.ifMatchPrecondition(w, r, movie)
+	if !ok {
+		return
+	}
+
+	var patch moviePatchRequest
+	if err := decodeJSONBody(w, r, &patch); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+
+	req, err := patch.mergeInto(movie)
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+	s.applyMovieUpdate(w, r, movie, req, expected)
+}
+
+func (s *Server) handleDeleteMovie(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	r = s.withActor(r)
+
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	expected, ok := s.ifMatchPrecondition(w, r, movie)
+	if !ok {
+		return
+	}
+
+	if err := s.repo.Movies.Delete(r.Context(), movie.ID, expected); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return
+		case errors.Is(err, repository.ErrVersionMismatch):
+			s.respondError(w, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "Movie has been modified; refetch and retry")
+			return
+		}
+		s.logger.Printf("delete movie error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete movie")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestoreMovie(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	r = s.withActor(r)
+
 	title, err := decodeTitleParam(r)
 	if err != nil {
 		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
 		return
 	}
 
-	raterID := strings.TrimSpace(r.Header.Get("X-Rater-Id"))
-	if raterID == "" {
-		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+	year, err := parseYearParam(r)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
 		return
 	}
 
-	movie, err := s.repo.Movies.GetByTitle(r.Context(), title)
+	deleted, err := s.repo.Movies.GetDeletedByTitle(r.Context(), title, year)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
 			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
 			return
 		}
-		s.logger.Printf("fetch movie for rating failed: %v", err)
-		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to process rating")
+		s.logger.Printf("fetch deleted movie error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to restore movie")
+		return
+	}
+
+	movie, err := s.repo.Movies.Restore(r.Context(), deleted.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		case errors.Is(err, repository.ErrConflict):
+			s.respondTitleConflict(w, r, deleted.Title, deleted.ReleaseYear)
+		default:
+			s.logger.Printf("restore movie error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to restore movie")
+		}
+		return
+	}
+	s.respondMovieDetail(w, r, movie)
+}
+
+// applyMovieUpdate validates the full replacement payload and persists it.
+func (s *Server) applyMovieUpdate(w http.ResponseWriter, r *http.Request, movie domain.Movie, req movieCreateRequest, expectedUpdatedAt *time.Time) {
+	params, err := req.toParams()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	updated, err := s.repo.Movies.Update(r.Context(), movie.ID, repository.MovieUpdateParams{
+		Title:             params.Title,
+		ReleaseDate:       params.ReleaseDate,
+		Genre:             params.Genre,
+		Genres:            params.Genres,
+		Distributor:       params.Distributor,
+		Budget:            params.Budget,
+		MpaRating:         params.MpaRating,
+		Metadata:          params.Metadata,
+		AlternateTitles:   params.AlternateTitles,
+		Certifications:    params.Certifications,
+		Releases:          params.Releases,
+		ExpectedUpdatedAt: expectedUpdatedAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		case errors.Is(err, repository.ErrVersionMismatch):
+			s.respondError(w, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "Movie has been modified; refetch and retry")
+		case repository.IsTitleYearConflict(err):
+			s.respondTitleConflict(w, r, params.Title, params.ReleaseDate.Year())
+		case errors.Is(err, repository.ErrConflict):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", conflictMessage(err))
+		case errors.Is(err, repository.ErrUnknownGenre):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", unknownGenreMessage(err))
+		case errors.Is(err, repository.ErrConstraint):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", constraintMessage(err))
+		default:
+			s.logger.Printf("update movie error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update movie")
+		}
+		return
+	}
+	s.respondMovieDetail(w, r, updated)
+}
+
+// mergeInto overlays the patch onto the current movie state, producing a full
+// replacement payload.
+func (p moviePatchRequest) mergeInto(movie domain.Movie) (movieCreateRequest, error) {
+	req := movieCreateRequest{
+		Title:           movie.Title,
+		Genre:           movie.Genre,
+		Genres:          movie.Genres,
+		ReleaseDate:     movie.ReleaseDate.Format("2006-01-02"),
+		Distributor:     movie.Distributor,
+		Budget:          movie.Budget,
+		MpaRating:       movie.MpaRating,
+		AlternateTitles: toAlternateTitlePayloads(movie.AlternateTitles),
+		Certifications:  toCertificationPayloads(movie.Certifications),
+		Releases:        toReleasePayloads(movie.Releases),
+
+		RuntimeMinutes:      movie.RuntimeMinutes,
+		OriginalLanguage:    movie.OriginalLanguage,
+		SpokenLanguages:     movie.SpokenLanguages,
+		ProductionCountries: movie.ProductionCountries,
+		Synopsis:            movie.Synopsis,
+		PosterURL:           movie.PosterURL,
+	}
+	if p.Title.Set {
+		if p.Title.Value == nil {
+			return req, fmt.Errorf("title cannot be null")
+		}
+		req.Title = *p.Title.Value
+	}
+	if p.Genres.Set {
+		if p.Genres.Value == nil {
+			return req, fmt.Errorf("genres cannot be null")
+		}
+		// The first listed genre becomes primary unless genre is patched too.
+		req.Genre = ""
+		req.Genres = *p.Genres.Value
+	}
+	if p.Genre.Set {
+		if p.Genre.Value == nil {
+			return req, fmt.Errorf("genre cannot be null")
+		}
+		req.Genre = *p.Genre.Value
+	}
+	if p.ReleaseDate.Set {
+		if p.ReleaseDate.Value == nil {
+			return req, fmt.Errorf("releaseDate cannot be null")
+		}
+		req.ReleaseDate = *p.ReleaseDate.Value
+	}
+	if p.Distributor.Set {
+		req.Distributor = p.Distributor.Value
+	}
+	if p.Budget.Set {
+		req.Budget = p.Budget.Value
+	}
+	if p.MpaRating.Set {
+		req.MpaRating = p.MpaRating.Value
+	}
+	if p.AlternateTitles.Set {
+		req.AlternateTitles = nil
+		if p.AlternateTitles.Value != nil {
+			req.AlternateTitles = *p.AlternateTitles.Value
+		}
+	}
+	if p.Certifications.Set {
+		req.Certifications = nil
+		if p.Certifications.Value != nil {
+			req.Certifications = *p.Certifications.Value
+		}
+	}
+	if p.Releases.Set {
+		req.Releases = nil
+		if p.Releases.Value != nil {
+			req.Releases = *p.Releases.Value
+		}
+	}
+	if p.RuntimeMinutes.Set {
+		req.RuntimeMinutes = p.RuntimeMinutes.Value
+	}
+	if p.OriginalLanguage.Set {
+		req.OriginalLanguage = p.OriginalLanguage.Value
+	}
+	if p.SpokenLanguages.Set {
+		req.SpokenLanguages = nil
+		if p.SpokenLanguages.Value != nil {
+			req.SpokenLanguages = *p.SpokenLanguages.Value
+		}
+	}
+	if p.ProductionCountries.Set {
+		req.ProductionCountries = nil
+		if p.ProductionCountries.Value != nil {
+			req.ProductionCountries = *p.ProductionCountries.Value
+		}
+	}
+	if p.Synopsis.Set {
+		req.Synopsis = p.Synopsis.Value
+	}
+	if p.PosterURL.Set {
+		req.PosterURL = p.PosterURL.Value
+	}
+	return req, nil
+}
+
+// movieFromPath resolves the {title} path parameter, writing the error response
+// itself when the movie cannot be loaded. The parameter may be a slug; retired
+// slugs redirect to the current one. Otherwise remakes sharing a title are told
+// apart by ?year= or a "<title>-<year>" path segment; when the title is still
+// ambiguous the candidates are returned with 300 (reads) or 409 (writes).
+func (s *Server) movieFromPath(w http.ResponseWriter, r *http.Request) (domain.Movie, bool) {
+	return s.resolveMovieFromPath(w, r, false)
+}
+
+// resolveMovieFromPath is movieFromPath; with includeDeleted it falls back to
+// the most recently soft-deleted movie when no live movie matches.
+func (s *Server) resolveMovieFromPath(w http.ResponseWriter, r *http.Request, includeDeleted bool) (domain.Movie, bool) {
+	title, err := decodeTitleParam(r)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return domain.Movie{}, false
+	}
+	year, err := parseYearParam(r)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return domain.Movie{}, false
+	}
+
+	if year == nil {
+		movie, redirected, err := s.repo.Movies.GetBySlug(r.Context(), title)
+		switch {
+		case err == nil && redirected:
+			s.redirectToSlug(w, r, movie)
+			return domain.Movie{}, false
+		case err == nil:
+			return movie, true
+		case !errors.Is(err, repository.ErrNotFound):
+			s.logger.Printf("fetch movie by slug error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie")
+			return domain.Movie{}, false
+		}
+	}
+
+	candidates, err := s.repo.Movies.FindByTitle(r.Context(), title, year)
+	if err == nil && len(candidates) == 0 && year == nil {
+		if base, suffixYear, ok := splitYearSuffix(title); ok {
+			candidates, err = s.repo.Movies.FindByTitle(r.Context(), base, &suffixYear)
+		}
+	}
+	if err != nil {
+		s.logger.Printf("fetch movie error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie")
+		return domain.Movie{}, false
+	}
+
+	switch len(candidates) {
+	case 0:
+		if includeDeleted {
+			movie, err := s.repo.Movies.GetDeletedByTitle(r.Context(), title, year)
+			if err == nil {
+				return movie, true
+			}
+			if !errors.Is(err, repository.ErrNotFound) {
+				s.logger.Printf("fetch deleted movie error: %v", err)
+				s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie")
+				return domain.Movie{}, false
+			}
+		}
+		s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		return domain.Movie{}, false
+	case 1:
+		return candidates[0], true
+	default:
+		s.respondAmbiguous(w, r, candidates)
+		return domain.Movie{}, false
+	}
+}
+
+// redirectToSlug points a request made with a retired slug at the movie's
+// current slug, keeping the rest of the path and the query string. Reads get
+// 301; other methods get 308 so clients replay the method and body.
+func (s *Server) redirectToSlug(w http.ResponseWriter, r *http.Request, movie domain.Movie) {
+	path := r.URL.EscapedPath()
+	raw := chi.URLParam(r, "title")
+	target := movieLocation(movie)
+	if idx := strings.Index(path, "/movies/"+raw); idx >= 0 {
+		target = path[:idx] + target + path[idx+len("/movies/")+len(raw):]
+	}
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	status := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		status = http.StatusMovedPermanently
+	}
+	w.Header().Set("Location", target)
+	s.respondJSON(w, status, errorResponse{
+		Code:    "MOVED",
+		Message: "Movie has moved to a new slug",
+		Details: map[string]interface{}{"location": target},
+	})
+}
+
+type movieCandidate struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	Location    string `json:"location"`
+}
+
+func (s *Server) respondAmbiguous(w http.ResponseWriter, r *http.Request, movies []domain.Movie) {
+	status := http.StatusConflict
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		status = http.StatusMultipleChoices
+	}
+	candidates := make([]movieCandidate, 0, len(movies))
+	for _, movie := range movies {
+		candidates = append(candidates, movieCandidate{
+			ID:          movie.ID,
+			Title:       movie.Title,
+			ReleaseDate: movie.ReleaseDate.Format("2006-01-02"),
+			Location:    movieLocation(movie),
+		})
+	}
+	s.respondJSON(w, status, errorResponse{
+		Code:    "AMBIGUOUS_TITLE",
+		Message: "Several movies share this title; specify the release year",
+		Details: map[string]interface{}{"candidates": candidates},
+	})
+}
+
+// respondTitleConflict answers a write that collided with a live movie of the
+// same title and release year, pointing Location at that movie so clients can
+// tell "already exists" apart from a failure.
+func (s *Server) respondTitleConflict(w http.ResponseWriter, r *http.Request, title string, year int) {
+	resp := errorResponse{
+		Code:    "CONFLICT",
+		Message: "A movie with this title and release year already exists",
+	}
+	existing, err := s.repo.Movies.GetByTitleYear(r.Context(), title, year)
+	switch {
+	case err == nil:
+		location := movieLocation(existing)
+		w.Header().Set("Location", location)
+		resp.Details = map[string]interface{}{"id": existing.ID, "location": location}
+	case !errors.Is(err, repository.ErrNotFound):
+		s.logger.Printf("fetch conflicting movie error: %v", err)
+	}
+	s.respondJSON(w, http.StatusConflict, resp)
+}
+
+// conflictMessage describes a unique violation other than the title/year one.
+// Nested collections are replaced wholesale, so such a violation means the
+// request itself repeats an entry, e.g. the same alternate title twice.
+func conflictMessage(err error) string {
+	msg := err.Error()
+	if idx := strings.Index(msg, ": repository: "); idx > 0 {
+		return msg[:idx]
+	}
+	var constraintErr *repository.ConstraintError
+	if errors.As(err, &constraintErr) && constraintErr.Constraint != "" {
+		return fmt.Sprintf("value violates unique constraint %s", constraintErr.Constraint)
+	}
+	return "value duplicates an existing entry"
+}
+
+// constraintMessage describes an ErrConstraint without the repository prefix.
+func constraintMessage(err error) string {
+	var constraintErr *repository.ConstraintError
+	if errors.As(err, &constraintErr) && constraintErr.Constraint != "" {
+		return fmt.Sprintf("value violates constraint %s", constraintErr.Constraint)
+	}
+	return "value violates a database constraint"
+}
+
+// movieLocation builds the canonical path of a movie from its slug, which
+// stays valid (via redirect) even if the title is edited later.
+func movieLocation(movie domain.Movie) string {
+	return "/movies/" + url.PathEscape(movie.Slug)
+}
+
+// parseYearParam reads the optional ?year= disambiguator for {title} routes,
+// validated like the year filters on GET /movies.
+func parseYearParam(r *http.Request) (*int, error) {
+	return parseYearBound(r.URL.Query(), "year")
+}
+
+// splitYearSuffix splits "dune-2021" into ("dune", 2021).
+func splitYearSuffix(title string) (string, int, bool) {
+	idx := strings.LastIndex(title, "-")
+	if idx <= 0 || len(title)-idx-1 != 4 {
+		return "", 0, false
+	}
+	year, err := strconv.Atoi(title[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return title[:idx], year, true
+}
+
+// respondMovieDetail writes the single-movie representation with its rating
+// aggregate embedded, or 304 when a read's If-None-Match still matches.
+func (s *Server) respondMovieDetail(w http.ResponseWriter, r *http.Request, movie domain.Movie) {
+	agg, err := s.repo.Ratings.Aggregate(r.Context(), movie.ID)
+	if err != nil {
+		s.logger.Printf("aggregate rating error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch movie")
+		return
+	}
+	etag := movieETag(movie, agg)
+	if s.notModified(w, r, etag) {
+		return
+	}
+
+	resp := toMovieResponse(movie)
+	resp.Rating = &ratingAggregateResponse{
+		Average: roundToOneDecimal(agg.Average),
+		Count:   agg.Count,
+	}
+	w.Header().Set("ETag", etag)
+	s.respondJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSubmitRating(w http.ResponseWriter, r *http.Request) {
+	raterID := strings.TrimSpace(r.Header.Get("X-Rater-Id"))
+	if raterID == "" {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+	// Keys are per rater, so two raters cannot collide on the same key.
+	s.idempotent(w, r, "POST /ratings "+raterID, s.submitRating)
+}
+
+// submitRating handles a POST /movies/{title}/ratings carrying X-Rater-Id.
+func (s *Server) submitRating(w http.ResponseWriter, r *http.Request) {
+	raterID := strings.TrimSpace(r.Header.Get("X-Rater-Id"))
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
 		return
 	}
 
@@ -294,20 +1529,8 @@ func (s *Server) handleSubmitRating(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleGetRating(w http.ResponseWriter, r *http.Request) {
-	title, err := decodeTitleParam(r)
-	if err != nil {
-		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
-		return
-	}
-
-	movie, err := s.repo.Movies.GetByTitle(r.Context(), title)
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
-			return
-		}
-		s.logger.Printf("fetch movie for rating agg failed: %v", err)
-		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch rating")
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
 		return
 	}
 
@@ -371,12 +1594,26 @@ func (s *Server) respondDecodeError(w http.ResponseWriter, err error) {
 func toMovieResponse(movie domain.Movie) movieResponse {
 	resp := movieResponse{
 		ID:          movie.ID,
+		Slug:        movie.Slug,
 		Title:       movie.Title,
 		ReleaseDate: movie.ReleaseDate.Format("2006-01-02"),
 		Genre:       movie.Genre,
 		Distributor: movie.Distributor,
 		Budget:      movie.Budget,
 		MpaRating:   movie.MpaRating,
+		DeletedAt:   movie.DeletedAt,
+
+		RuntimeMinutes:      movie.RuntimeMinutes,
+		OriginalLanguage:    movie.OriginalLanguage,
+		SpokenLanguages:     codesOrEmpty(movie.SpokenLanguages),
+		ProductionCountries: codesOrEmpty(movie.ProductionCountries),
+		Synopsis:            movie.Synopsis,
+		PosterURL:           movie.PosterURL,
+
+		Genres:          movie.Genres,
+		AlternateTitles: toAlternateTitlePayloads(movie.AlternateTitles),
+		Certifications:  toCertificationPayloads(movie.Certifications),
+		Releases:        toReleasePayloads(movie.Releases),
 	}
 	if movie.BoxOffice != nil {
 		resp.BoxOffice = &boxOfficeResponse{
@@ -403,6 +1640,14 @@ func normalizeStringPtr(ptr *string) *string {
 	return &val
 }
 
+// codesOrEmpty keeps code lists rendering as [] rather than null.
+func codesOrEmpty(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+	return codes
+}
+
 func firstNonNil[T any](primary, fallback *T) *T {
 	if primary != nil {
 		return primary
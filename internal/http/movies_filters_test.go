@@ -1,10 +1,16 @@
 package httpserver
 
 import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/config"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
 )
 
 func TestBuildMovieFilters(t *testing.T) {
@@ -20,13 +26,13 @@ func TestBuildMovieFilters(t *testing.T) {
 	if filters.Year == nil || *filters.Year != 2010 {
 		t.Fatalf("year parse failed: %+v", filters.Year)
 	}
-	if filters.Genre == nil || *filters.Genre != "Action" {
-		t.Fatalf("genre parse failed: %+v", filters.Genre)
+	if len(filters.Genres) != 1 || filters.Genres[0] != "Action" {
+		t.Fatalf("genre parse failed: %+v", filters.Genres)
 	}
 	if filters.Distributor == nil || *filters.Distributor != "Warner" {
 		t.Fatalf("distributor parse failed")
 	}
-	if filters.BudgetLTE == nil || *filters.BudgetLTE != 100000000 {
+	if filters.BudgetMax == nil || *filters.BudgetMax != 100000000 {
 		t.Fatalf("budget parse failed")
 	}
 	if filters.MpaRating == nil || *filters.MpaRating != "PG-13" {
@@ -38,9 +44,185 @@ func TestBuildMovieFilters(t *testing.T) {
 }
 
 func TestBuildMovieFilters_InvalidYear(t *testing.T) {
-	values, _ := url.ParseQuery("year=abc")
+	for _, query := range []string{"year=abc", "year=0", "year=10000"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("expected error for %s", query)
+		}
+	}
+}
+
+func TestParseYearParam(t *testing.T) {
+	year, err := parseYearParam(httptest.NewRequest(http.MethodGet, "/movies/Dune?year=1984", nil))
+	if err != nil || year == nil || *year != 1984 {
+		t.Fatalf("year = %v, %v; want 1984", year, err)
+	}
+	for _, target := range []string{"/movies/Dune?year=abc", "/movies/Dune?year=0", "/movies/Dune?year=-5", "/movies/Dune?year=20210"} {
+		if _, err := parseYearParam(httptest.NewRequest(http.MethodGet, target, nil)); err == nil {
+			t.Fatalf("%s: expected an invalid year error", target)
+		}
+	}
+}
+
+func TestBuildMovieFilters_Deleted(t *testing.T) {
+	values, _ := url.ParseQuery("deleted=only")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters.Deleted != repository.DeletedOnly {
+		t.Fatalf("deleted = %q, want only", filters.Deleted)
+	}
+
+	values, _ = url.ParseQuery("deleted=yes")
 	if _, err := buildMovieFilters(values); err == nil {
-		t.Fatalf("expected error for invalid year")
+		t.Fatalf("expected error for invalid deleted value")
+	}
+}
+
+func TestBuildMovieFilters_Sort(t *testing.T) {
+	values, _ := url.ParseQuery("q=dark knight&sort=Relevance")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters.Sort != repository.MovieSortRelevance {
+		t.Fatalf("sort = %q, want relevance", filters.Sort)
+	}
+
+	values, _ = url.ParseQuery("q=dark&sort=relevance")
+	if filters, err = buildMovieFilters(values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fingerprint, err := repository.FilterFingerprint(filters)
+	if err != nil {
+		t.Fatalf("FilterFingerprint: %v", err)
+	}
+	relevanceCursor := base64.StdEncoding.EncodeToString([]byte(`{"sort":"relevance","filter":"` + fingerprint + `","value":"0.7","id":"m1"}`))
+	values, _ = url.ParseQuery("q=dark&sort=relevance&limit=5&cursor=" + url.QueryEscape(relevanceCursor))
+	if filters, err = buildMovieFilters(values); err != nil || filters.Cursor.Value == nil || *filters.Cursor.Value != "0.7" {
+		t.Fatalf("relevance cursor not accepted: %v %+v", err, filters.Cursor)
+	}
+
+	values, _ = url.ParseQuery("sort=budget:asc")
+	if filters, err = buildMovieFilters(values); err != nil || filters.Sort != repository.MovieSortBudget || filters.Order != repository.SortOrderAsc {
+		t.Fatalf("budget:asc = %+v, %v", filters, err)
+	}
+	values, _ = url.ParseQuery("sort=releaseDate")
+	if filters, err = buildMovieFilters(values); err != nil || filters.Sort != repository.MovieSortReleaseDate || filters.Order != repository.SortOrderDefault {
+		t.Fatalf("releaseDate = %+v, %v", filters, err)
+	}
+
+	for _, query := range []string{
+		"sort=relevance",
+		"q=dark&sort=popularity",
+		"sort=title:up",
+		"sort=title:",
+		"q=dark&cursor=" + url.QueryEscape(relevanceCursor),
+		"q=light&sort=relevance&cursor=" + url.QueryEscape(relevanceCursor),
+	} {
+		values, _ = url.ParseQuery(query)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("expected error for %s", query)
+		}
+	}
+}
+
+func TestBuildMovieFilters_Ranges(t *testing.T) {
+	values, _ := url.ParseQuery("yearFrom=2005&yearTo=2012&budgetMin=50000000&budgetMax=200000000&revenueMin=1000000000&releasedAfter=2005-01-01&releasedBefore=2012-12-31")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters.YearFrom == nil || *filters.YearFrom != 2005 || filters.YearTo == nil || *filters.YearTo != 2012 {
+		t.Fatalf("unexpected year range: %v %v", filters.YearFrom, filters.YearTo)
+	}
+	if filters.BudgetMin == nil || *filters.BudgetMin != 50000000 || filters.BudgetMax == nil || *filters.BudgetMax != 200000000 {
+		t.Fatalf("unexpected budget range: %v %v", filters.BudgetMin, filters.BudgetMax)
+	}
+	if filters.RevenueMin == nil || *filters.RevenueMin != 1000000000 || filters.RevenueMax != nil {
+		t.Fatalf("unexpected revenue range: %v %v", filters.RevenueMin, filters.RevenueMax)
+	}
+
+	values, _ = url.ParseQuery("minRating=4&minVotes=50&sort=rating")
+	if filters, err = buildMovieFilters(values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters.MinRating == nil || *filters.MinRating != 4 || filters.MinVotes == nil || *filters.MinVotes != 50 || filters.Sort != repository.MovieSortRating {
+		t.Fatalf("unexpected rating filters: %+v", filters)
+	}
+
+	for query, param := range map[string]string{
+		"yearFrom=twenty":            "yearFrom",
+		"yearTo=0":                   "yearTo",
+		"yearFrom=2012&yearTo=2005":  "yearFrom",
+		"budgetMin=-1":               "budgetMin",
+		"budgetMax=1e6":              "budgetMax",
+		"budgetMin=10&budgetMax=5":   "budgetMin",
+		"budget=10&budgetMax=20":     "budgetMax",
+		"revenueMin=abc":             "revenueMin",
+		"minRating=5.5":              "minRating",
+		"minRating=NaN":              "minRating",
+		"minVotes=-3":                "minVotes",
+		"revenueMin=10&revenueMax=5": "revenueMax",
+		"releasedAfter=2012-01-01&releasedBefore=2011-01-01": "releasedBefore",
+	} {
+		values, _ = url.ParseQuery(query)
+		_, err := buildMovieFilters(values)
+		if err == nil {
+			t.Fatalf("expected error for %s", query)
+		}
+		if !strings.Contains(err.Error(), param) {
+			t.Fatalf("error for %s does not name %s: %v", query, param, err)
+		}
+	}
+}
+
+func TestParseFacetParams(t *testing.T) {
+	values, _ := url.ParseQuery("facets=genre,MPARATING&facets=year&total=true")
+	facets, withTotal, err := parseFacetParams(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []repository.MovieFacet{repository.MovieFacetGenre, repository.MovieFacetMpaRating, repository.MovieFacetYear}
+	if !withTotal || len(facets) != len(want) {
+		t.Fatalf("parseFacetParams = %v, %v", facets, withTotal)
+	}
+	for i := range want {
+		if facets[i] != want[i] {
+			t.Fatalf("facets[%d] = %q, want %q", i, facets[i], want[i])
+		}
+	}
+
+	values, _ = url.ParseQuery("q=dune")
+	if facets, withTotal, err = parseFacetParams(values); err != nil || len(facets) != 0 || withTotal {
+		t.Fatalf("expected no facets by default, got %v %v %v", facets, withTotal, err)
+	}
+
+	for _, query := range []string{"facets=budget", "total=maybe"} {
+		values, _ = url.ParseQuery(query)
+		if _, _, err := parseFacetParams(values); err == nil {
+			t.Fatalf("expected error for %s", query)
+		}
+	}
+}
+
+func TestBuildMovieFilters_Genres(t *testing.T) {
+	values, _ := url.ParseQuery("genre=sci-fi, Drama&genre=Thriller&genreMatch=all")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filters.Genres) != 3 || filters.Genres[0] != "sci-fi" || filters.Genres[1] != "Drama" || filters.Genres[2] != "Thriller" {
+		t.Fatalf("genres = %v", filters.Genres)
+	}
+	if filters.GenreMatch != repository.GenreMatchAll {
+		t.Fatalf("genreMatch = %q, want all", filters.GenreMatch)
+	}
+
+	values, _ = url.ParseQuery("genre=Drama&genreMatch=some")
+	if _, err := buildMovieFilters(values); err == nil {
+		t.Fatalf("expected error for invalid genreMatch value")
 	}
 }
 
@@ -62,3 +244,94 @@ func TestVerifyBearer(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildMovieFilters_Person(t *testing.T) {
+	values, _ := url.ParseQuery("person= Christopher Nolan &role=Director")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filters.Person == nil || *filters.Person != "Christopher Nolan" {
+		t.Fatalf("person = %v", filters.Person)
+	}
+	if filters.PersonRole == nil || *filters.PersonRole != domain.CreditRoleDirector {
+		t.Fatalf("role = %v", filters.PersonRole)
+	}
+
+	for _, raw := range []string{"person=x&role=gaffer", "role=actor"} {
+		values, _ = url.ParseQuery(raw)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestBuildMovieFilters_LanguageCountry(t *testing.T) {
+	values, _ := url.ParseQuery("language=EN,ja&country=cn")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filters.Languages) != 2 || filters.Languages[0] != "en" || filters.Languages[1] != "ja" {
+		t.Fatalf("languages = %v", filters.Languages)
+	}
+	if len(filters.Countries) != 1 || filters.Countries[0] != "CN" {
+		t.Fatalf("countries = %v", filters.Countries)
+	}
+
+	for _, raw := range []string{"language=english", "country=UK"} {
+		values, _ = url.ParseQuery(raw)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestBuildMovieFilters_Certification(t *testing.T) {
+	values, _ := url.ParseQuery("certification=GB:15,us:pg13&mpaRating=nc17")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []domain.Certification{
+		{Country: "GB", System: "BBFC", Rating: "15"},
+		{Country: "US", System: "MPA", Rating: "PG-13"},
+	}
+	if len(filters.Certifications) != 2 || filters.Certifications[0] != want[0] || filters.Certifications[1] != want[1] {
+		t.Fatalf("certifications = %+v", filters.Certifications)
+	}
+	if filters.MpaRating == nil || *filters.MpaRating != "NC-17" {
+		t.Fatalf("mpaRating = %v", filters.MpaRating)
+	}
+
+	for _, raw := range []string{"certification=GB", "certification=FR:12", "certification=DE:15", "mpaRating=PG-15"} {
+		values, _ = url.ParseQuery(raw)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("%s: expected error", raw)
+		}
+	}
+}
+
+func TestBuildMovieFilters_Releases(t *testing.T) {
+	values, _ := url.ParseQuery("releasedIn=cn,GB&releasedAfter=2010-09-01&releasedBefore=2010-12-31")
+	filters, err := buildMovieFilters(values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filters.ReleasedIn) != 2 || filters.ReleasedIn[0] != "CN" || filters.ReleasedIn[1] != "GB" {
+		t.Fatalf("releasedIn = %v", filters.ReleasedIn)
+	}
+	if filters.ReleasedAfter == nil || filters.ReleasedAfter.Format("2006-01-02") != "2010-09-01" {
+		t.Fatalf("releasedAfter = %v", filters.ReleasedAfter)
+	}
+	if filters.ReleasedBefore == nil || filters.ReleasedBefore.Format("2006-01-02") != "2010-12-31" {
+		t.Fatalf("releasedBefore = %v", filters.ReleasedBefore)
+	}
+
+	for _, raw := range []string{"releasedIn=China", "releasedAfter=2010", "releasedBefore=soon"} {
+		values, _ = url.ParseQuery(raw)
+		if _, err := buildMovieFilters(values); err == nil {
+			t.Fatalf("%s: expected error", raw)
+		}
+	}
+}
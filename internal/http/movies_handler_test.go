@@ -23,6 +23,7 @@ import (
 
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/boxoffice"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/config"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
 )
 
@@ -42,6 +43,7 @@ func buildTestServer(tb testing.TB) *Server {
 		WriteTimeoutSecs:     15,
 		IdleTimeoutSecs:      60,
 		BoxOfficeTimeoutSecs: 1,
+		IdempotencyTTLHours:  24,
 	}
 
 	pool, cleanup := newTestPool(tb)
@@ -191,6 +193,107 @@ func TestHandleListMovies_InvalidYear(t *testing.T) {
 	}
 }
 
+func TestHandleListMovies_RatingFilterAndInlineAggregate(t *testing.T) {
+	srv := buildTestServer(t)
+	ctx := context.Background()
+
+	for i, title := range []string{"Top Rated", "Barely Rated"} {
+		movie, err := srv.repo.Movies.Create(ctx, repository.MovieCreateParams{
+			Title:       title,
+			Genre:       "Drama",
+			ReleaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			t.Fatalf("create movie: %v", err)
+		}
+		for rater := 0; rater <= 1-i; rater++ {
+			if _, _, err := srv.repo.Ratings.Upsert(ctx, repository.RatingUpsertParams{
+				MovieID: movie.ID,
+				RaterID: fmt.Sprintf("user%d", rater),
+				Value:   4.5,
+			}); err != nil {
+				t.Fatalf("upsert rating: %v", err)
+			}
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies?minRating=4&minVotes=2&sort=rating", nil)
+	rec := httptest.NewRecorder()
+	srv.handleListMovies(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp movieListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Items) != 1 || resp.Items[0].Title != "Top Rated" {
+		t.Fatalf("unexpected items: %+v", resp.Items)
+	}
+	if rating := resp.Items[0].Rating; rating == nil || rating.Average != 4.5 || rating.Count != 2 {
+		t.Fatalf("unexpected inline rating: %+v", rating)
+	}
+}
+
+func TestHandleListMovies_SparseFieldsAndCredits(t *testing.T) {
+	srv := buildTestServer(t)
+	ctx := context.Background()
+
+	movie, err := srv.repo.Movies.Create(ctx, repository.MovieCreateParams{
+		Title:       "Memento",
+		Genre:       "Thriller",
+		ReleaseDate: time.Date(2000, 9, 5, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+	person, err := srv.repo.People.Create(ctx, repository.PersonParams{Name: "Christopher Nolan"})
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	if _, err := srv.repo.People.ReplaceCredits(ctx, movie.ID, []repository.CreditParams{{PersonID: person.ID, Role: domain.CreditRoleDirector}}); err != nil {
+		t.Fatalf("replace credits: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies?fields=title,posterUrl&include=credits", nil)
+	rec := httptest.NewRecorder()
+	srv.handleListMovies(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Items []map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Items) != 1 {
+		t.Fatalf("expected one item, got %d", len(resp.Items))
+	}
+	item := resp.Items[0]
+	for _, key := range []string{"id", "title", "credits"} {
+		if _, ok := item[key]; !ok {
+			t.Fatalf("missing %q in %v", key, item)
+		}
+	}
+	for _, key := range []string{"boxOffice", "genre", "rating", "releases"} {
+		if _, ok := item[key]; ok {
+			t.Fatalf("unexpected %q in sparse item", key)
+		}
+	}
+	var credits []creditResponse
+	if err := json.Unmarshal(item["credits"], &credits); err != nil || len(credits) != 1 || credits[0].Name != "Christopher Nolan" {
+		t.Fatalf("unexpected credits: %s (%v)", item["credits"], err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/movies?fields=cast", nil)
+	rec = httptest.NewRecorder()
+	srv.handleListMovies(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for an unknown field", rec.Code)
+	}
+}
+
 func TestHandleGetRating_NotFound(t *testing.T) {
 	srv := buildTestServer(t)
 	req := httptest.NewRequest(http.MethodGet, "/movies/Nope/rating", nil)
@@ -203,6 +306,381 @@ func TestHandleGetRating_NotFound(t *testing.T) {
 	}
 }
 
+func TestHandleGetMovie_EmbedsRating(t *testing.T) {
+	srv := buildTestServer(t)
+
+	movie, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+		Title:       "Deep Link",
+		Genre:       "Drama",
+		ReleaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+	if _, _, err := srv.repo.Ratings.Upsert(context.Background(), repository.RatingUpsertParams{
+		MovieID: movie.ID,
+		RaterID: "user1",
+		Value:   4.5,
+	}); err != nil {
+		t.Fatalf("upsert rating: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/Deep%20Link", nil)
+	req = attachTitleParam(req, "Deep%20Link")
+	rec := httptest.NewRecorder()
+
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp movieResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.ID != movie.ID || resp.Title != "Deep Link" {
+		t.Fatalf("unexpected movie: %+v", resp)
+	}
+	if resp.Rating == nil || resp.Rating.Count != 1 || resp.Rating.Average != 4.5 {
+		t.Fatalf("unexpected rating: %+v", resp.Rating)
+	}
+
+	idReq := httptest.NewRequest(http.MethodGet, "/movies:byId/"+movie.ID, nil)
+	idReq = attachIDParam(idReq, movie.ID)
+	idRec := httptest.NewRecorder()
+	srv.handleGetMovieByID(idRec, idReq)
+	if idRec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (by id)", idRec.Code)
+	}
+}
+
+func TestHandleGetMovieByID_NotFound(t *testing.T) {
+	srv := buildTestServer(t)
+
+	for _, id := range []string{"not-a-uuid", "00000000-0000-0000-0000-000000000000"} {
+		req := httptest.NewRequest(http.MethodGet, "/movies:byId/"+id, nil)
+		req = attachIDParam(req, id)
+		rec := httptest.NewRecorder()
+
+		srv.handleGetMovieByID(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("status = %d, want 404 for %q", rec.Code, id)
+		}
+	}
+}
+
+func TestHandlePatchMovie_Conflict(t *testing.T) {
+	srv := buildTestServer(t)
+
+	for _, title := range []string{"Original", "Existing"} {
+		if _, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+			Title:       title,
+			Genre:       "Action",
+			ReleaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}); err != nil {
+			t.Fatalf("create movie: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/movies/Original", bytes.NewBufferString(`{"title":"Existing"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req = attachTitleParam(req, "Original")
+	rec := httptest.NewRecorder()
+	srv.handlePatchMovie(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/movies/Original", nil)
+	req = attachTitleParam(req, "Original")
+	rec = httptest.NewRecorder()
+	srv.handleDeleteMovie(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestHandleCreateMovie_DuplicateTitle(t *testing.T) {
+	srv := buildTestServer(t)
+
+	existing, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+		Title:       "Heat",
+		Genre:       "Action",
+		ReleaseDate: time.Date(1995, 12, 15, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+
+	body := `{"title":"heat","genre":"Action","releaseDate":"1995-01-01"}`
+	req := httptest.NewRequest(http.MethodPost, "/movies", bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	srv.handleCreateMovie(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Location"); got != movieLocation(existing) {
+		t.Fatalf("Location = %q, want %q", got, movieLocation(existing))
+	}
+}
+
+func TestHandleCreateMovie_IdempotencyKey(t *testing.T) {
+	srv := buildTestServer(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/movies", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set("Idempotency-Key", "create-heat")
+		rec := httptest.NewRecorder()
+		srv.handleCreateMovie(rec, req)
+		return rec
+	}
+
+	body := `{"title":"Heat","genre":"Action","releaseDate":"1995-12-15"}`
+	first := post(body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d, want 201: %s", first.Code, first.Body.String())
+	}
+
+	retry := post(body)
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("retry status = %d, want replayed 201: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("Location") != first.Header().Get("Location") {
+		t.Fatalf("expected replayed response, got headers %v", retry.Header())
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("replayed body differs:\n%s\n%s", first.Body.String(), retry.Body.String())
+	}
+
+	reused := post(`{"title":"Heat","genre":"Action","releaseDate":"1986-01-01"}`)
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key status = %d, want 422", reused.Code)
+	}
+
+	list, err := srv.repo.Movies.FindByTitle(context.Background(), "Heat", nil)
+	if err != nil {
+		t.Fatalf("FindByTitle: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected a single movie, got %d", len(list))
+	}
+}
+
+func TestMovieConditionalRequests(t *testing.T) {
+	srv := buildTestServer(t)
+
+	if _, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+		Title:       "Cached",
+		Genre:       "Action",
+		ReleaseDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+
+	req := attachTitleParam(httptest.NewRequest(http.MethodGet, "/movies/Cached", nil), "Cached")
+	rec := httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, etag = %q; want 200 with ETag", rec.Code, etag)
+	}
+
+	req = attachTitleParam(httptest.NewRequest(http.MethodGet, "/movies/Cached", nil), "Cached")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", rec.Code)
+	}
+
+	// A new rating changes the embedded aggregate, so the cached copy is stale.
+	req = attachTitleParam(httptest.NewRequest(http.MethodPost, "/movies/Cached/ratings", bytes.NewBufferString(`{"rating":4.0}`)), "Cached")
+	req.Header.Set("X-Rater-Id", "user1")
+	rec = httptest.NewRecorder()
+	srv.handleSubmitRating(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("rating status = %d, want 201", rec.Code)
+	}
+	req = attachTitleParam(httptest.NewRequest(http.MethodGet, "/movies/Cached", nil), "Cached")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("status = %d, etag = %q; want 200 with a new ETag after rating", rec.Code, rec.Header().Get("ETag"))
+	}
+	etag = rec.Header().Get("ETag")
+
+	req = attachTitleParam(httptest.NewRequest(http.MethodPatch, "/movies/Cached", bytes.NewBufferString(`{"genre":"Drama"}`)), "Cached")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handlePatchMovie(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	// The first editor's tag is now stale.
+	req = attachTitleParam(httptest.NewRequest(http.MethodPatch, "/movies/Cached", bytes.NewBufferString(`{"genre":"Comedy"}`)), "Cached")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("If-Match", etag)
+	rec = httptest.NewRecorder()
+	srv.handlePatchMovie(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412", rec.Code)
+	}
+}
+
+func TestHandleSubmitRating_AmbiguousTitle(t *testing.T) {
+	srv := buildTestServer(t)
+
+	for _, year := range []int{1984, 2021} {
+		if _, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+			Title:       "Dune",
+			Genre:       "Sci-Fi",
+			ReleaseDate: time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+		}); err != nil {
+			t.Fatalf("create movie: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/movies/Dune/ratings", bytes.NewBufferString(`{"rating":4.0}`))
+	req.Header.Set("X-Rater-Id", "user1")
+	req = attachTitleParam(req, "Dune")
+	rec := httptest.NewRecorder()
+	srv.handleSubmitRating(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want 409", rec.Code)
+	}
+	var body struct {
+		Code    string `json:"code"`
+		Details struct {
+			Candidates []movieCandidate `json:"candidates"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if body.Code != "AMBIGUOUS_TITLE" || len(body.Details.Candidates) != 2 {
+		t.Fatalf("unexpected ambiguity response: %s", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/movies/Dune", nil)
+	req = attachTitleParam(req, "Dune")
+	rec = httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusMultipleChoices {
+		t.Fatalf("status = %d, want 300", rec.Code)
+	}
+
+	for _, target := range []string{"/movies/Dune/ratings?year=2021", "/movies/dune-2021/ratings"} {
+		req = httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(`{"rating":4.0}`))
+		req.Header.Set("X-Rater-Id", "user1")
+		title := "Dune"
+		if target == "/movies/dune-2021/ratings" {
+			title = "dune-2021"
+		}
+		req = attachTitleParam(req, title)
+		rec = httptest.NewRecorder()
+		srv.handleSubmitRating(rec, req)
+		if rec.Code != http.StatusCreated && rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 201/200", target, rec.Code)
+		}
+	}
+}
+
+func TestMovieSlugRedirect(t *testing.T) {
+	srv := buildTestServer(t)
+
+	movie, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+		Title:       "Blade Runner",
+		Genre:       "Sci-Fi",
+		ReleaseDate: time.Date(1982, 6, 25, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/blade-runner-1982", nil)
+	req = attachTitleParam(req, movie.Slug)
+	rec := httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get by slug status = %d, want 200", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/movies/blade-runner-1982", bytes.NewBufferString(`{"title":"Blade Runner: The Final Cut"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req = attachTitleParam(req, movie.Slug)
+	rec = httptest.NewRecorder()
+	srv.handlePatchMovie(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/movies/blade-runner-1982/rating", nil)
+	req = attachTitleParam(req, "blade-runner-1982")
+	rec = httptest.NewRecorder()
+	srv.handleGetRating(rec, req)
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("old slug status = %d, want 301", rec.Code)
+	}
+	if got := rec.Header().Get("Location"); got != "/movies/blade-runner-the-final-cut-1982/rating" {
+		t.Fatalf("Location = %q", got)
+	}
+}
+
+func TestHandleMovieHistory_DeletedMovie(t *testing.T) {
+	srv := buildTestServer(t)
+
+	if _, err := srv.repo.Movies.Create(context.Background(), repository.MovieCreateParams{
+		Title:       "Solaris",
+		Genre:       "Sci-Fi",
+		ReleaseDate: time.Date(1972, 3, 20, 0, 0, 0, 0, time.UTC),
+	}); err != nil {
+		t.Fatalf("create movie: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/movies/Solaris", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Actor-Id", "editor-1")
+	req = attachTitleParam(req, "Solaris")
+	rec := httptest.NewRecorder()
+	srv.handleDeleteMovie(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/movies/Solaris/history", nil)
+	req = attachTitleParam(req, "Solaris")
+	rec = httptest.NewRecorder()
+	srv.handleMovieHistory(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("history status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	var history revisionListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if len(history.Items) != 2 || history.Items[0].Actor != "editor-1" || history.Items[1].Actor != "system" {
+		t.Fatalf("unexpected history: %+v", history.Items)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/movies/Solaris", nil)
+	req = attachTitleParam(req, "Solaris")
+	rec = httptest.NewRecorder()
+	srv.handleGetMovie(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("get deleted movie status = %d, want 404", rec.Code)
+	}
+}
+
+func attachIDParam(req *http.Request, id string) *http.Request {
+	ctx := chi.NewRouteContext()
+	ctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, ctx))
+}
+
 func attachTitleParam(req *http.Request, title string) *http.Request {
 	ctx := chi.NewRouteContext()
 	ctx.URLParams.Add("title", title)
@@ -1,8 +1,14 @@
 package httpserver
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"testing"
+	"time"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
 )
 
 func TestRoundToOneDecimal(t *testing.T) {
@@ -43,3 +49,214 @@ func TestAllowedRatings(t *testing.T) {
 		}
 	}
 }
+
+func TestMoviePatchRequest_MergeInto(t *testing.T) {
+	distributor := "Warner"
+	budget := int64(100)
+	movie := domain.Movie{
+		Title:       "Inceptoin",
+		Genre:       "Sci-Fi",
+		ReleaseDate: time.Date(2010, 7, 16, 0, 0, 0, 0, time.UTC),
+		Distributor: &distributor,
+		Budget:      &budget,
+	}
+
+	var patch moviePatchRequest
+	if err := json.Unmarshal([]byte(`{"title":"Inception","distributor":null}`), &patch); err != nil {
+		t.Fatalf("decode patch: %v", err)
+	}
+	req, err := patch.mergeInto(movie)
+	if err != nil {
+		t.Fatalf("mergeInto: %v", err)
+	}
+	if req.Title != "Inception" || req.Genre != "Sci-Fi" || req.ReleaseDate != "2010-07-16" {
+		t.Fatalf("unexpected merged request: %+v", req)
+	}
+	if req.Distributor != nil {
+		t.Fatalf("explicit null should clear distributor")
+	}
+	if req.Budget == nil || *req.Budget != 100 {
+		t.Fatalf("absent budget should be kept")
+	}
+
+	var nullTitle moviePatchRequest
+	if err := json.Unmarshal([]byte(`{"title":null}`), &nullTitle); err != nil {
+		t.Fatalf("decode patch: %v", err)
+	}
+	if _, err := nullTitle.mergeInto(movie); err == nil {
+		t.Fatalf("expected error when nulling title")
+	}
+}
+
+func TestSplitYearSuffix(t *testing.T) {
+	tests := []struct {
+		in       string
+		wantBase string
+		wantYear int
+		wantOK   bool
+	}{
+		{"dune-2021", "dune", 2021, true},
+		{"blade-runner-2049-2017", "blade-runner-2049", 2017, true},
+		{"dune", "", 0, false},
+		{"-2021", "", 0, false},
+		{"dune-21", "", 0, false},
+		{"dune-abcd", "", 0, false},
+	}
+	for _, tt := range tests {
+		base, year, ok := splitYearSuffix(tt.in)
+		if base != tt.wantBase || year != tt.wantYear || ok != tt.wantOK {
+			t.Fatalf("splitYearSuffix(%q) = (%q, %d, %v), want (%q, %d, %v)", tt.in, base, year, ok, tt.wantBase, tt.wantYear, tt.wantOK)
+		}
+	}
+}
+
+func TestParseAlternateTitles(t *testing.T) {
+	lang := " ZH "
+	region := "cn"
+	titles, err := parseAlternateTitles([]alternateTitlePayload{
+		{Title: " 盗梦空间 ", Language: &lang, Region: &region, Kind: "localized"},
+		{Title: "Origen", Kind: "LOCALIZED"},
+	})
+	if err != nil {
+		t.Fatalf("parseAlternateTitles: %v", err)
+	}
+	if len(titles) != 2 || titles[0].Title != "盗梦空间" || *titles[0].Language != "zh" || *titles[0].Region != "CN" {
+		t.Fatalf("unexpected titles: %+v", titles)
+	}
+	if titles[1].Kind != domain.TitleKindLocalized || titles[1].Language != nil {
+		t.Fatalf("unexpected second title: %+v", titles[1])
+	}
+
+	bad := "chinese"
+	invalid := map[string][]alternateTitlePayload{
+		"empty title":   {{Title: " ", Kind: "working"}},
+		"unknown kind":  {{Title: "Origen", Kind: "dubbed"}},
+		"bad language":  {{Title: "Origen", Language: &bad, Kind: "localized"}},
+		"duplicate row": {{Title: "Origen", Kind: "localized"}, {Title: "origen", Kind: "working"}},
+	}
+	for name, payload := range invalid {
+		if _, err := parseAlternateTitles(payload); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMovieCreateRequest_Metadata(t *testing.T) {
+	runtime := 148
+	lang := " EN "
+	poster := "https://img.example.com/inception.jpg"
+	req := movieCreateRequest{
+		RuntimeMinutes:      &runtime,
+		OriginalLanguage:    &lang,
+		SpokenLanguages:     []string{"en", "JA", "en"},
+		ProductionCountries: []string{"us", "GB"},
+		PosterURL:           &poster,
+	}
+	meta, err := req.metadata()
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if *meta.OriginalLanguage != "en" || len(meta.SpokenLanguages) != 2 || meta.SpokenLanguages[1] != "ja" {
+		t.Fatalf("unexpected languages: %+v", meta)
+	}
+	if len(meta.ProductionCountries) != 2 || meta.ProductionCountries[0] != "US" {
+		t.Fatalf("unexpected countries: %v", meta.ProductionCountries)
+	}
+
+	zero := 0
+	badLang := "xx"
+	relative := "/posters/1.jpg"
+	invalid := map[string]movieCreateRequest{
+		"zero runtime":     {RuntimeMinutes: &zero},
+		"unknown language": {OriginalLanguage: &badLang},
+		"spoken language":  {SpokenLanguages: []string{"eng"}},
+		"country":          {ProductionCountries: []string{"UK"}},
+		"relative poster":  {PosterURL: &relative},
+	}
+	for name, req := range invalid {
+		if _, err := req.metadata(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMovieCreateRequest_MpaRating(t *testing.T) {
+	rating := "pg13 "
+	params, err := movieCreateRequest{Title: "Inception", Genre: "Sci-Fi", ReleaseDate: "2010-07-16", MpaRating: &rating}.toParams()
+	if err != nil {
+		t.Fatalf("toParams: %v", err)
+	}
+	if params.MpaRating == nil || *params.MpaRating != "PG-13" {
+		t.Fatalf("mpaRating = %v, want PG-13", params.MpaRating)
+	}
+
+	bad := "PG-15"
+	if _, err := (movieCreateRequest{Title: "Inception", Genre: "Sci-Fi", ReleaseDate: "2010-07-16", MpaRating: &bad}).toParams(); err == nil {
+		t.Fatalf("expected error for unknown mpaRating")
+	}
+}
+
+func TestParseCertifications(t *testing.T) {
+	certs, err := parseCertifications([]certificationPayload{
+		{Country: "gb", Rating: "12a"},
+		{Country: "DE", System: "fsk", Rating: "FSK 16"},
+	})
+	if err != nil {
+		t.Fatalf("parseCertifications: %v", err)
+	}
+	if len(certs) != 2 || certs[0] != (domain.Certification{Country: "GB", System: "BBFC", Rating: "12A"}) || certs[1].Rating != "16" {
+		t.Fatalf("unexpected certifications: %+v", certs)
+	}
+
+	invalid := map[string][]certificationPayload{
+		"unknown country": {{Country: "XX", Rating: "15"}},
+		"no system":       {{Country: "FR", Rating: "12"}},
+		"us":              {{Country: "US", Rating: "R"}},
+		"wrong system":    {{Country: "GB", System: "FSK", Rating: "15"}},
+		"unknown rating":  {{Country: "DE", Rating: "15"}},
+		"duplicate":       {{Country: "GB", Rating: "15"}, {Country: "gb", Rating: "18"}},
+	}
+	for name, payload := range invalid {
+		if _, err := parseCertifications(payload); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestParseReleases(t *testing.T) {
+	releases, err := parseReleases([]releasePayload{
+		{Country: "cn", Date: "2010-09-01", Type: "Theatrical"},
+		{Country: "CN", Date: "2011-01-10", Type: "digital"},
+	})
+	if err != nil {
+		t.Fatalf("parseReleases: %v", err)
+	}
+	if len(releases) != 2 || releases[0].Country != "CN" || releases[0].Type != domain.ReleaseTypeTheatrical {
+		t.Fatalf("unexpected releases: %+v", releases)
+	}
+	if !releases[1].Date.Equal(time.Date(2011, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected date: %v", releases[1].Date)
+	}
+
+	invalid := map[string][]releasePayload{
+		"country":   {{Country: "China", Date: "2010-09-01", Type: "theatrical"}},
+		"date":      {{Country: "CN", Date: "2010/09/01", Type: "theatrical"}},
+		"type":      {{Country: "CN", Date: "2010-09-01", Type: "tv"}},
+		"duplicate": {{Country: "CN", Date: "2010-09-01", Type: "festival"}, {Country: "cn", Date: "2010-09-02", Type: "festival"}},
+	}
+	for name, payload := range invalid {
+		if _, err := parseReleases(payload); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestConflictMessage(t *testing.T) {
+	err := fmt.Errorf("duplicate alternate title %q: %w", "Origen", repository.ErrConflict)
+	if got := conflictMessage(err); got != `duplicate alternate title "Origen"` {
+		t.Fatalf("conflictMessage = %q", got)
+	}
+	if got := conflictMessage(repository.ErrConflict); got != "value duplicates an existing entry" {
+		t.Fatalf("conflictMessage = %q", got)
+	}
+}
@@ -0,0 +1,367 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/repository"
+)
+
+type personRequest struct {
+	Name      string  `json:"name"`
+	BirthDate *string `json:"birthDate"`
+	Biography *string `json:"biography"`
+}
+
+type personResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	BirthDate *string           `json:"birthDate,omitempty"`
+	Biography *string           `json:"biography,omitempty"`
+	Credits   []filmographyItem `json:"credits,omitempty"`
+}
+
+type filmographyItem struct {
+	MovieID      string  `json:"movieId"`
+	Title        string  `json:"title"`
+	Slug         string  `json:"slug"`
+	ReleaseDate  string  `json:"releaseDate"`
+	Role         string  `json:"role"`
+	Character    *string `json:"character,omitempty"`
+	BillingOrder int     `json:"billingOrder"`
+}
+
+type personListResponse struct {
+	Items      []personResponse `json:"items"`
+	NextCursor *string          `json:"nextCursor,omitempty"`
+}
+
+type creditRequest struct {
+	PersonID     string  `json:"personId"`
+	Role         string  `json:"role"`
+	Character    *string `json:"character"`
+	BillingOrder *int    `json:"billingOrder"`
+}
+
+type creditResponse struct {
+	ID           int64   `json:"id"`
+	PersonID     string  `json:"personId"`
+	Name         string  `json:"name"`
+	Role         string  `json:"role"`
+	Character    *string `json:"character,omitempty"`
+	BillingOrder int     `json:"billingOrder"`
+}
+
+type creditListResponse struct {
+	Items []creditResponse `json:"items"`
+}
+
+func (s *Server) handleListPeople(w http.ResponseWriter, r *http.Request) {
+	filters, err := buildPeopleFilters(r.URL.Query())
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	result, err := s.repo.People.List(r.Context(), filters)
+	if err != nil {
+		s.logger.Printf("list people error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list people")
+		return
+	}
+
+	items := make([]personResponse, 0, len(result.Items))
+	for _, person := range result.Items {
+		items = append(items, toPersonResponse(person, nil))
+	}
+	s.respondJSON(w, http.StatusOK, personListResponse{Items: items, NextCursor: result.NextCursor})
+}
+
+func buildPeopleFilters(query url.Values) (repository.PeopleListFilters, error) {
+	var filters repository.PeopleListFilters
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		filters.Query = &q
+	}
+	if val := strings.TrimSpace(query.Get("limit")); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			return filters, fmt.Errorf("invalid limit value")
+		}
+		filters.Limit = limit
+	}
+	if val := strings.TrimSpace(query.Get("cursor")); val != "" {
+		cursor, err := repository.DecodePersonCursor(val)
+		if err != nil {
+			return filters, fmt.Errorf("invalid cursor")
+		}
+		filters.Cursor = cursor
+	}
+	return filters, nil
+}
+
+func (s *Server) handleCreatePerson(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req personRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	person, err := s.repo.People.Create(r.Context(), params)
+	if err != nil {
+		s.logger.Printf("create person error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create person")
+		return
+	}
+	w.Header().Set("Location", "/people/"+person.ID)
+	s.respondJSON(w, http.StatusCreated, toPersonResponse(person, nil))
+}
+
+func (s *Server) handleGetPerson(w http.ResponseWriter, r *http.Request) {
+	person, ok := s.personFromPath(w, r)
+	if !ok {
+		return
+	}
+	credits, err := s.repo.People.CreditsByPerson(r.Context(), person.ID)
+	if err != nil {
+		s.logger.Printf("fetch filmography error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch person")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, toPersonResponse(person, credits))
+}
+
+func (s *Server) handleReplacePerson(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req personRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	params, err := req.toParams()
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	person, err := s.repo.People.Update(r.Context(), chi.URLParam(r, "id"), params)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return
+		}
+		s.logger.Printf("update person error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update person")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, toPersonResponse(person, nil))
+}
+
+func (s *Server) handleDeletePerson(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	if err := s.repo.People.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+		case errors.Is(err, repository.ErrConstraint):
+			s.respondError(w, http.StatusConflict, "CONFLICT", "Person still has movie credits; remove them first")
+		default:
+			s.logger.Printf("delete person error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete person")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetMovieCredits(w http.ResponseWriter, r *http.Request) {
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+	credits, err := s.repo.People.CreditsByMovie(r.Context(), movie.ID)
+	if err != nil {
+		s.logger.Printf("fetch credits error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch credits")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, toCreditListResponse(credits))
+}
+
+func (s *Server) handleReplaceMovieCredits(w http.ResponseWriter, r *http.Request) {
+	if !s.verifyBearer(r.Header.Get("Authorization")) {
+		s.respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Missing or invalid authentication information")
+		return
+	}
+
+	var req []creditRequest
+	if err := decodeJSONBody(w, r, &req); err != nil {
+		s.respondDecodeError(w, err)
+		return
+	}
+	params, err := parseCredits(req)
+	if err != nil {
+		s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	movie, ok := s.movieFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	credits, err := s.repo.People.ReplaceCredits(r.Context(), movie.ID, params)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUnknownPerson):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", strings.TrimPrefix(err.Error(), "repository: "))
+		case errors.Is(err, repository.ErrConflict):
+			s.respondError(w, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "credits contain duplicate entries")
+		default:
+			s.logger.Printf("replace credits error: %v", err)
+			s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update credits")
+		}
+		return
+	}
+	s.respondJSON(w, http.StatusOK, toCreditListResponse(credits))
+}
+
+// personFromPath loads the person named by the {id} path parameter, writing
+// the error response itself on failure.
+func (s *Server) personFromPath(w http.ResponseWriter, r *http.Request) (domain.Person, bool) {
+	person, err := s.repo.People.GetByID(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			s.respondError(w, http.StatusNotFound, "NOT_FOUND", "Resource not found")
+			return domain.Person{}, false
+		}
+		s.logger.Printf("get person error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch person")
+		return domain.Person{}, false
+	}
+	return person, true
+}
+
+// toParams validates the payload and normalizes it into repository parameters.
+func (req personRequest) toParams() (repository.PersonParams, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return repository.PersonParams{}, fmt.Errorf("name is required")
+	}
+	params := repository.PersonParams{Name: name, Biography: normalizeStringPtr(req.Biography)}
+	if birth := normalizeStringPtr(req.BirthDate); birth != nil {
+		date, err := time.Parse("2006-01-02", *birth)
+		if err != nil {
+			return repository.PersonParams{}, fmt.Errorf("birthDate must follow YYYY-MM-DD format")
+		}
+		params.BirthDate = &date
+	}
+	return params, nil
+}
+
+// parseCredits validates credits; billingOrder defaults to the entry's
+// position in the list.
+func parseCredits(req []creditRequest) ([]repository.CreditParams, error) {
+	params := make([]repository.CreditParams, 0, len(req))
+	for i, item := range req {
+		personID := strings.TrimSpace(item.PersonID)
+		if personID == "" {
+			return nil, fmt.Errorf("credits[%d].personId is required", i)
+		}
+		role, ok := parseCreditRole(item.Role)
+		if !ok {
+			return nil, fmt.Errorf("credits[%d].role is not a supported role", i)
+		}
+		character := normalizeStringPtr(item.Character)
+		if character != nil && role != domain.CreditRoleActor {
+			return nil, fmt.Errorf("credits[%d].character is only allowed for actors", i)
+		}
+		order := i
+		if item.BillingOrder != nil {
+			if *item.BillingOrder < 0 {
+				return nil, fmt.Errorf("credits[%d].billingOrder must be non-negative", i)
+			}
+			order = *item.BillingOrder
+		}
+		params = append(params, repository.CreditParams{
+			PersonID:     personID,
+			Role:         role,
+			Character:    character,
+			BillingOrder: order,
+		})
+	}
+	return params, nil
+}
+
+func parseCreditRole(val string) (domain.CreditRole, bool) {
+	val = strings.ToLower(strings.TrimSpace(val))
+	for _, role := range domain.CreditRoles {
+		if string(role) == val {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+func toPersonResponse(person domain.Person, credits []domain.Credit) personResponse {
+	resp := personResponse{ID: person.ID, Name: person.Name, Biography: person.Biography}
+	if person.BirthDate != nil {
+		birth := person.BirthDate.Format("2006-01-02")
+		resp.BirthDate = &birth
+	}
+	if credits != nil {
+		resp.Credits = make([]filmographyItem, 0, len(credits))
+		for _, credit := range credits {
+			resp.Credits = append(resp.Credits, filmographyItem{
+				MovieID:      credit.MovieID,
+				Title:        credit.MovieTitle,
+				Slug:         credit.MovieSlug,
+				ReleaseDate:  credit.MovieReleaseDate.Format("2006-01-02"),
+				Role:         string(credit.Role),
+				Character:    credit.Character,
+				BillingOrder: credit.BillingOrder,
+			})
+		}
+	}
+	return resp
+}
+
+func toCreditListResponse(credits []domain.Credit) creditListResponse {
+	items := make([]creditResponse, 0, len(credits))
+	for _, credit := range credits {
+		items = append(items, creditResponse{
+			ID:           credit.ID,
+			PersonID:     credit.PersonID,
+			Name:         credit.PersonName,
+			Role:         string(credit.Role),
+			Character:    credit.Character,
+			BillingOrder: credit.BillingOrder,
+		})
+	}
+	return creditListResponse{Items: items}
+}
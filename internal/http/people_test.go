@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"testing"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+func TestParseCredits(t *testing.T) {
+	cobb := "Cobb"
+	order := 5
+	params, err := parseCredits([]creditRequest{
+		{PersonID: "p1", Role: "Director"},
+		{PersonID: "p2", Role: "actor", Character: &cobb, BillingOrder: &order},
+	})
+	if err != nil {
+		t.Fatalf("parseCredits: %v", err)
+	}
+	if params[0].Role != domain.CreditRoleDirector || params[0].BillingOrder != 0 {
+		t.Fatalf("unexpected first credit: %+v", params[0])
+	}
+	if params[1].Character == nil || *params[1].Character != "Cobb" || params[1].BillingOrder != 5 {
+		t.Fatalf("unexpected second credit: %+v", params[1])
+	}
+
+	negative := -1
+	invalid := map[string][]creditRequest{
+		"missing person":    {{Role: "actor"}},
+		"unknown role":      {{PersonID: "p1", Role: "gaffer"}},
+		"director as cobb":  {{PersonID: "p1", Role: "director", Character: &cobb}},
+		"negative ordering": {{PersonID: "p1", Role: "writer", BillingOrder: &negative}},
+	}
+	for name, req := range invalid {
+		if _, err := parseCredits(req); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPersonRequest_ToParams(t *testing.T) {
+	birth := "1970-07-30"
+	params, err := personRequest{Name: " Christopher Nolan ", BirthDate: &birth}.toParams()
+	if err != nil {
+		t.Fatalf("toParams: %v", err)
+	}
+	if params.Name != "Christopher Nolan" || params.BirthDate == nil || params.BirthDate.Year() != 1970 {
+		t.Fatalf("unexpected params: %+v", params)
+	}
+
+	bad := "30/07/1970"
+	if _, err := (personRequest{Name: "Nolan", BirthDate: &bad}).toParams(); err == nil {
+		t.Fatalf("expected error for malformed birthDate")
+	}
+	if _, err := (personRequest{Name: "  "}).toParams(); err == nil {
+		t.Fatalf("expected error for empty name")
+	}
+}
@@ -25,6 +25,7 @@ type Server struct {
 	logger    *log.Logger
 	router    chi.Router
 	httpSrv   *http.Server
+	enrich    *enrichQueue
 }
 
 // New constructs the HTTP server with base middleware and routes.
@@ -47,16 +48,48 @@ func New(cfg config.Config, st *store.Store, repo *repository.Repository, boxCli
 		logger:    logger,
 		router:    r,
 	}
+	s.enrich = newEnrichQueue(enrichWorkers, enrichQueueSize, s.enrichMovieByID)
 	s.registerRoutes()
 	return s
 }
 
 func (s *Server) registerRoutes() {
 	s.router.Get("/healthz", s.handleHealthz)
+	s.router.Get("/genres", s.handleListGenres)
+	s.router.Post("/genres", s.handleCreateGenre)
+	s.router.Route("/collections", func(r chi.Router) {
+		r.Get("/", s.handleListCollections)
+		r.Post("/", s.handleCreateCollection)
+		r.Get("/{id}", s.handleGetCollection)
+		r.Put("/{id}", s.handleReplaceCollection)
+		r.Delete("/{id}", s.handleDeleteCollection)
+		r.Put("/{id}/movies", s.handleReplaceCollectionMovies)
+	})
+	s.router.Route("/people", func(r chi.Router) {
+		r.Get("/", s.handleListPeople)
+		r.Post("/", s.handleCreatePerson)
+		r.Get("/{id}", s.handleGetPerson)
+		r.Put("/{id}", s.handleReplacePerson)
+		r.Delete("/{id}", s.handleDeletePerson)
+	})
+	s.router.Post("/movies:import", s.handleImportMovies)
+	s.router.Get("/movies:export", s.handleExportMovies)
+	// Lookups other than by title live beside /movies rather than under it,
+	// so every /movies/{title} path is left to titles and slugs.
+	s.router.Get("/movies:suggest", s.handleSuggestMovies)
+	s.router.Get("/movies:byId/{id}", s.handleGetMovieByID)
 	s.router.Route("/movies", func(r chi.Router) {
 		r.Get("/", s.handleListMovies)
 		r.Post("/", s.handleCreateMovie)
 		r.Route("/{title}", func(r chi.Router) {
+			r.Get("/", s.handleGetMovie)
+			r.Put("/", s.handleReplaceMovie)
+			r.Patch("/", s.handlePatchMovie)
+			r.Delete("/", s.handleDeleteMovie)
+			r.Post("/restore", s.handleRestoreMovie)
+			r.Get("/history", s.handleMovieHistory)
+			r.Get("/credits", s.handleGetMovieCredits)
+			r.Put("/credits", s.handleReplaceMovieCredits)
 			r.Post("/ratings", s.handleSubmitRating)
 			r.Get("/rating", s.handleGetRating)
 		})
@@ -93,12 +126,17 @@ func (s *Server) Start(ctx context.Context) error {
 	}
 }
 
-// Shutdown gracefully stops the HTTP server.
+// Shutdown gracefully stops the HTTP server, then waits for queued box office
+// enrichment to finish.
 func (s *Server) Shutdown(ctx context.Context) error {
-	if s.httpSrv == nil {
-		return nil
+	var err error
+	if s.httpSrv != nil {
+		err = s.httpSrv.Shutdown(ctx)
+	}
+	if enrichErr := s.enrich.close(ctx); err == nil {
+		err = enrichErr
 	}
-	return s.httpSrv.Shutdown(ctx)
+	return err
 }
 
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
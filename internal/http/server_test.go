@@ -0,0 +1,30 @@
+package httpserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRoutesLeaveTitlePathsToTitles(t *testing.T) {
+	srv := &Server{router: chi.NewRouter()}
+	srv.registerRoutes()
+
+	cases := map[string]string{
+		"/movies/suggest":    "/movies/{title}",
+		"/movies/id":         "/movies/{title}",
+		"/movies/id/history": "/movies/{title}/history",
+		"/movies:suggest":    "/movies:suggest",
+		"/movies:byId/m1":    "/movies:byId/{id}",
+	}
+	for path, want := range cases {
+		rctx := chi.NewRouteContext()
+		if !srv.router.Match(rctx, http.MethodGet, path) {
+			t.Fatalf("GET %s matched no route", path)
+		}
+		if got := rctx.RoutePattern(); got != want {
+			t.Fatalf("GET %s routed to %s, want %s", path, got, want)
+		}
+	}
+}
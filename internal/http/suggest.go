@@ -0,0 +1,212 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxSuggestPrefixLength bounds the text accepted by GET /movies:suggest.
+	maxSuggestPrefixLength = 100
+	// highlightSimilarity is the trigram similarity a title word needs to be
+	// highlighted as a fuzzy match, mirroring pg_trgm's default threshold.
+	highlightSimilarity = 0.3
+)
+
+type suggestHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+type movieSuggestionResponse struct {
+	ID          string             `json:"id"`
+	Slug        string             `json:"slug"`
+	Title       string             `json:"title"`
+	ReleaseYear int                `json:"releaseYear"`
+	Score       float64            `json:"score"`
+	Highlights  []suggestHighlight `json:"highlights"`
+}
+
+type movieSuggestionsResponse struct {
+	Items []movieSuggestionResponse `json:"items"`
+}
+
+func (s *Server) handleSuggestMovies(w http.ResponseWriter, r *http.Request) {
+	prefix, limit, err := parseSuggestQuery(r.URL.Query())
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	suggestions, err := s.repo.Movies.Suggest(r.Context(), prefix, limit)
+	if err != nil {
+		s.logger.Printf("suggest movies error: %v", err)
+		s.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to suggest movies")
+		return
+	}
+
+	items := make([]movieSuggestionResponse, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		items = append(items, movieSuggestionResponse{
+			ID:          suggestion.ID,
+			Slug:        suggestion.Slug,
+			Title:       suggestion.Title,
+			ReleaseYear: suggestion.ReleaseYear,
+			Score:       suggestion.Score,
+			Highlights:  suggestHighlights(suggestion.Title, prefix),
+		})
+	}
+	s.respondJSON(w, http.StatusOK, movieSuggestionsResponse{Items: items})
+}
+
+func parseSuggestQuery(query url.Values) (string, int, error) {
+	prefix := strings.Join(strings.Fields(query.Get("prefix")), " ")
+	if prefix == "" {
+		return "", 0, fmt.Errorf("prefix is required")
+	}
+	if utf8.RuneCountInString(prefix) > maxSuggestPrefixLength {
+		return "", 0, fmt.Errorf("prefix must be at most %d characters", maxSuggestPrefixLength)
+	}
+	var limit int
+	if val := strings.TrimSpace(query.Get("limit")); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return "", 0, fmt.Errorf("invalid limit value")
+		}
+		limit = n
+	}
+	return prefix, limit, nil
+}
+
+// suggestHighlights returns the spans of title, in characters (code points)
+// with an exclusive end, that match prefix. A literal occurrence is preferred,
+// at a word start when there is one; otherwise each typed word highlights the
+// title word it most resembles, so "Incepshun" marks "Inception".
+func suggestHighlights(title, prefix string) []suggestHighlight {
+	titleRunes := []rune(strings.ToLower(title))
+	needle := []rune(strings.ToLower(prefix))
+	if len(titleRunes) != utf8.RuneCountInString(title) {
+		// Lower-casing changed the length; offsets would not line up.
+		return []suggestHighlight{}
+	}
+
+	if start := indexRunes(titleRunes, needle); start >= 0 {
+		return []suggestHighlight{{Start: start, End: start + len(needle)}}
+	}
+
+	words := wordSpans(titleRunes)
+	typedWords := wordSpans(needle)
+	var highlights []suggestHighlight
+	for i, typed := range typedWords {
+		typedWord := string(needle[typed.Start:typed.End])
+		best, bestScore := suggestHighlight{}, 0.0
+		for _, word := range words {
+			candidate := word
+			score := trigramSimilarity(typedWord, string(titleRunes[word.Start:word.End]))
+			// The last typed word may be incomplete; when it does not resemble
+			// a whole title word, compare it with the beginning of one.
+			if n := typed.End - typed.Start; i == len(typedWords)-1 && score < highlightSimilarity && n < word.End-word.Start {
+				if partial := trigramSimilarity(typedWord, string(titleRunes[word.Start:word.Start+n])); partial > score {
+					score, candidate = partial, suggestHighlight{Start: word.Start, End: word.Start + n}
+				}
+			}
+			if score > bestScore {
+				best, bestScore = candidate, score
+			}
+		}
+		if bestScore >= highlightSimilarity {
+			highlights = append(highlights, best)
+		}
+	}
+	return mergeHighlights(highlights)
+}
+
+// indexRunes finds needle in haystack, preferring a match at a word start.
+func indexRunes(haystack, needle []rune) int {
+	first := -1
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) != string(needle) {
+			continue
+		}
+		if i == 0 || !isWordRune(haystack[i-1]) {
+			return i
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	return first
+}
+
+func wordSpans(text []rune) []suggestHighlight {
+	var spans []suggestHighlight
+	start := -1
+	for i, r := range text {
+		switch {
+		case isWordRune(r) && start < 0:
+			start = i
+		case !isWordRune(r) && start >= 0:
+			spans = append(spans, suggestHighlight{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, suggestHighlight{Start: start, End: len(text)})
+	}
+	return spans
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func mergeHighlights(spans []suggestHighlight) []suggestHighlight {
+	if len(spans) == 0 {
+		return []suggestHighlight{}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	merged := spans[:1]
+	for _, span := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if span.Start <= last.End {
+			if span.End > last.End {
+				last.End = span.End
+			}
+			continue
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// trigramSimilarity computes pg_trgm's similarity for a single lower-cased
+// word: the shared fraction of trigrams of the word padded with two leading
+// blanks and one trailing blank.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(word string) map[string]struct{} {
+	padded := []rune("  " + word + " ")
+	set := make(map[string]struct{}, len(padded))
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = struct{}{}
+	}
+	return set
+}
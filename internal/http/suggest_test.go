@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestHighlights(t *testing.T) {
+	cases := []struct {
+		title, prefix string
+		want          []suggestHighlight
+	}{
+		{"Inception", "incep", []suggestHighlight{{0, 5}}},
+		{"The Dark Knight", "dark", []suggestHighlight{{4, 8}}},
+		{"Anna and the King", "an", []suggestHighlight{{0, 2}}},
+		{"Inception", "Incepshun", []suggestHighlight{{0, 9}}},
+		{"The Dark Knight Rises", "dark knigt", []suggestHighlight{{4, 8}, {9, 15}}},
+		{"Inception", "incpe", []suggestHighlight{{0, 5}}},
+		{"Amélie", "AMÉ", []suggestHighlight{{0, 3}}},
+		{"Inception", "zzz", []suggestHighlight{}},
+	}
+	for _, tc := range cases {
+		got := suggestHighlights(tc.title, tc.prefix)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("suggestHighlights(%q, %q) = %v, want %v", tc.title, tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestParseSuggestQuery(t *testing.T) {
+	values, _ := url.ParseQuery("prefix=%20the%20%20dark%20&limit=5")
+	prefix, limit, err := parseSuggestQuery(values)
+	if err != nil || prefix != "the dark" || limit != 5 {
+		t.Fatalf("parseSuggestQuery = %q, %d, %v", prefix, limit, err)
+	}
+
+	for _, query := range []string{"", "prefix=%20", "prefix=a&limit=0", "prefix=a&limit=x"} {
+		values, _ := url.ParseQuery(query)
+		if _, _, err := parseSuggestQuery(values); err == nil {
+			t.Fatalf("expected error for %q", query)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package iso
+
+import "testing"
+
+func TestNormalizeLanguage(t *testing.T) {
+	if code, ok := NormalizeLanguage(" ZH "); !ok || code != "zh" {
+		t.Fatalf("NormalizeLanguage(ZH) = %q, %v", code, ok)
+	}
+	for _, code := range []string{"", "xx", "eng", "chinese"} {
+		if _, ok := NormalizeLanguage(code); ok {
+			t.Fatalf("NormalizeLanguage(%q) should fail", code)
+		}
+	}
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	if code, ok := NormalizeCountry("gb"); !ok || code != "GB" {
+		t.Fatalf("NormalizeCountry(gb) = %q, %v", code, ok)
+	}
+	for _, code := range []string{"", "UK", "USA", "XX"} {
+		if _, ok := NormalizeCountry(code); ok {
+			t.Fatalf("NormalizeCountry(%q) should fail", code)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// IdempotencyPurgeStore is the subset of the idempotency repository the
+// purger needs.
+type IdempotencyPurgeStore interface {
+	PurgeExpired(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// IdempotencyPurger periodically removes Idempotency-Key records older than
+// their time to live.
+type IdempotencyPurger struct {
+	store    IdempotencyPurgeStore
+	ttl      time.Duration
+	interval time.Duration
+	logger   *log.Logger
+	now      func() time.Time
+}
+
+// NewIdempotencyPurger constructs a purger; call Run to start it.
+func NewIdempotencyPurger(store IdempotencyPurgeStore, ttl, interval time.Duration, logger *log.Logger) *IdempotencyPurger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &IdempotencyPurger{
+		store:    store,
+		ttl:      ttl,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every key created before now - ttl.
+func (p *IdempotencyPurger) PurgeOnce(ctx context.Context) {
+	cutoff := p.now().Add(-p.ttl)
+	removed, err := p.store.PurgeExpired(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Printf("jobs: idempotency key purge failed: %v", err)
+		}
+		return
+	}
+	if removed > 0 {
+		p.logger.Printf("jobs: purged %d idempotency keys created before %s", removed, cutoff.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+type fakeIdempotencyStore struct {
+	cutoffs []time.Time
+}
+
+func (f *fakeIdempotencyStore) PurgeExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	return 1, nil
+}
+
+func TestIdempotencyPurger_PurgeOnceUsesTTL(t *testing.T) {
+	store := &fakeIdempotencyStore{}
+	purger := NewIdempotencyPurger(store, 24*time.Hour, time.Hour, log.New(io.Discard, "", 0))
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	purger.now = func() time.Time { return now }
+
+	purger.PurgeOnce(context.Background())
+
+	if len(store.cutoffs) != 1 {
+		t.Fatalf("purge calls = %d, want 1", len(store.cutoffs))
+	}
+	if want := now.Add(-24 * time.Hour); !store.cutoffs[0].Equal(want) {
+		t.Fatalf("cutoff = %s, want %s", store.cutoffs[0], want)
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// TrashPurgeStore is the subset of the movies repository the purger needs.
+type TrashPurgeStore interface {
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// TrashPurger periodically removes soft-deleted movies older than the
+// retention window.
+type TrashPurger struct {
+	store     TrashPurgeStore
+	retention time.Duration
+	interval  time.Duration
+	logger    *log.Logger
+	now       func() time.Time
+}
+
+// NewTrashPurger constructs a purger; call Run to start it.
+func NewTrashPurger(store TrashPurgeStore, retention, interval time.Duration, logger *log.Logger) *TrashPurger {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &TrashPurger{
+		store:     store,
+		retention: retention,
+		interval:  interval,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce removes every movie tombstoned before now - retention.
+func (p *TrashPurger) PurgeOnce(ctx context.Context) {
+	cutoff := p.now().Add(-p.retention)
+	removed, err := p.store.PurgeDeleted(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			p.logger.Printf("jobs: trash purge failed: %v", err)
+		}
+		return
+	}
+	if removed > 0 {
+		p.logger.Printf("jobs: purged %d movies deleted before %s", removed, cutoff.Format(time.RFC3339))
+	}
+}
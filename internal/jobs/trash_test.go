@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+type fakePurgeStore struct {
+	cutoffs []time.Time
+}
+
+func (f *fakePurgeStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	f.cutoffs = append(f.cutoffs, cutoff)
+	return 1, nil
+}
+
+func TestTrashPurger_PurgeOnceUsesRetention(t *testing.T) {
+	store := &fakePurgeStore{}
+	purger := NewTrashPurger(store, 48*time.Hour, time.Hour, log.New(io.Discard, "", 0))
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	purger.now = func() time.Time { return now }
+
+	purger.PurgeOnce(context.Background())
+
+	if len(store.cutoffs) != 1 {
+		t.Fatalf("purge calls = %d, want 1", len(store.cutoffs))
+	}
+	if want := now.Add(-48 * time.Hour); !store.cutoffs[0].Equal(want) {
+		t.Fatalf("cutoff = %s, want %s", store.cutoffs[0], want)
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// certificationsColumn aggregates a movie's non-US certifications into a JSON
+// array ordered by country.
+const certificationsColumn = `(
+        SELECT COALESCE(json_agg(json_build_object(
+                   'country', c.country, 'system', c.system, 'rating', c.rating
+               ) ORDER BY c.country), '[]'::json)
+        FROM movie_certifications c
+        WHERE c.movie_id = movies.id
+    )`
+
+// replaceCertifications swaps the full set of certifications of a movie.
+func replaceCertifications(ctx context.Context, tx pgx.Tx, movieID string, certs []domain.Certification) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM movie_certifications WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO movie_certifications (movie_id, country, system, rating)
+            VALUES ($1, $2, $3, $4)
+        `, movieID, cert.Country, cert.System, cert.Rating)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("duplicate certification for %s: %w", cert.Country, translateConstraintError(err))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// certificationCondition matches movies holding any of certs. US ratings are
+// read from mpa_rating, others from movie_certifications.
+func certificationCondition(certs []domain.Certification, arg func(interface{}) string) string {
+	conds := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		if cert.Country == "US" {
+			conds = append(conds, fmt.Sprintf("mpa_rating = %s", arg(cert.Rating)))
+			continue
+		}
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+            SELECT 1 FROM movie_certifications c
+            WHERE c.movie_id = movies.id AND c.country = %s AND c.rating = %s
+        )`, arg(cert.Country), arg(cert.Rating)))
+	}
+	return "(" + strings.Join(conds, " OR ") + ")"
+}
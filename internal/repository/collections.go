@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// ErrUnknownMovie indicates a reference to a movie that does not exist or has
+// been deleted.
+var ErrUnknownMovie = errors.New("repository: unknown movie")
+
+// ErrInvalidMovieIDs indicates movie ids that are not UUIDs, or that name the
+// same movie more than once.
+var ErrInvalidMovieIDs = errors.New("repository: invalid movie ids")
+
+// CollectionsRepository manages movie collections and their membership.
+type CollectionsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// collectionColumns selects a collection with the number of live members.
+const collectionColumns = `
+    c.id,
+    c.name,
+    c.description,
+    (SELECT count(*) FROM collection_movies cm JOIN movies m ON m.id = cm.movie_id
+     WHERE cm.collection_id = c.id AND m.deleted_at IS NULL)::int,
+    c.created_at,
+    c.updated_at
+`
+
+// CollectionParams carries the editable fields of a collection.
+type CollectionParams struct {
+	Name        string
+	Description *string
+}
+
+// Create inserts a collection. It returns ErrConflict when the name is taken.
+func (r *CollectionsRepository) Create(ctx context.Context, params CollectionParams) (domain.Collection, error) {
+	var id string
+	err := r.pool.QueryRow(ctx, `INSERT INTO collections (name, description) VALUES ($1, $2) RETURNING id`,
+		params.Name, params.Description).Scan(&id)
+	if err != nil {
+		return domain.Collection{}, translateConstraintError(err)
+	}
+	return r.GetByID(ctx, id)
+}
+
+// GetByID fetches a collection by identifier.
+func (r *CollectionsRepository) GetByID(ctx context.Context, id string) (domain.Collection, error) {
+	query := fmt.Sprintf(`SELECT %s FROM collections c WHERE c.id = $1`, collectionColumns)
+	collection, err := scanCollection(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return domain.Collection{}, ErrNotFound
+		}
+		return domain.Collection{}, err
+	}
+	return collection, nil
+}
+
+// List returns all collections ordered by name.
+func (r *CollectionsRepository) List(ctx context.Context) ([]domain.Collection, error) {
+	query := fmt.Sprintf(`SELECT %s FROM collections c ORDER BY lower(c.name), c.id`, collectionColumns)
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := make([]domain.Collection, 0)
+	for rows.Next() {
+		collection, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, rows.Err()
+}
+
+// Update replaces the editable fields of a collection. Like Create, it returns
+// ErrConflict when the name is taken.
+func (r *CollectionsRepository) Update(ctx context.Context, id string, params CollectionParams) (domain.Collection, error) {
+	tag, err := r.pool.Exec(ctx, `UPDATE collections SET name = $2, description = $3 WHERE id = $1`,
+		id, params.Name, params.Description)
+	if err != nil {
+		if isInvalidTextRepresentation(err) {
+			return domain.Collection{}, ErrNotFound
+		}
+		return domain.Collection{}, translateConstraintError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.Collection{}, ErrNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+// Delete removes a collection; its movies are left untouched.
+func (r *CollectionsRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM collections WHERE id = $1`, id)
+	if err != nil {
+		if isInvalidTextRepresentation(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Movies returns the live members of a collection in collection order.
+func (r *CollectionsRepository) Movies(ctx context.Context, id string) ([]domain.Movie, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM movies
+        JOIN collection_movies cm ON cm.movie_id = movies.id
+        WHERE cm.collection_id = $1 AND movies.deleted_at IS NULL
+        ORDER BY cm.position, movies.release_date
+    `, movieColumns)
+	rows, err := r.pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]domain.Movie, 0)
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+	return movies, rows.Err()
+}
+
+// ReplaceMovies sets the ordered membership of a collection. Ids are compared
+// as UUIDs, so any spelling Postgres accepts works; malformed or repeated ids
+// fail with ErrInvalidMovieIDs, unknown or deleted movies with
+// ErrUnknownMovie.
+func (r *CollectionsRepository) ReplaceMovies(ctx context.Context, id string, movieIDs []string) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT true FROM collections WHERE id = $1 FOR UPDATE`, id).Scan(&exists); err != nil {
+			if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if len(movieIDs) > 0 {
+			var distinct, live int
+			err := tx.QueryRow(ctx, `
+                SELECT (SELECT count(DISTINCT u) FROM unnest($1::uuid[]) AS u),
+                       (SELECT count(*) FROM movies WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL)
+            `, movieIDs).Scan(&distinct, &live)
+			if err != nil {
+				if isInvalidTextRepresentation(err) {
+					return fmt.Errorf("%w: movie ids must be UUIDs", ErrInvalidMovieIDs)
+				}
+				return err
+			}
+			if distinct != len(movieIDs) {
+				return fmt.Errorf("%w: a movie is listed more than once", ErrInvalidMovieIDs)
+			}
+			if live != distinct {
+				return fmt.Errorf("%w: one or more movies do not exist or are deleted", ErrUnknownMovie)
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM collection_movies WHERE collection_id = $1`, id); err != nil {
+			return err
+		}
+		for position, movieID := range movieIDs {
+			_, err := tx.Exec(ctx, `INSERT INTO collection_movies (collection_id, movie_id, position) VALUES ($1, $2, $3)`,
+				id, movieID, position)
+			if err != nil {
+				return translateConstraintError(err)
+			}
+		}
+		_, err := tx.Exec(ctx, `UPDATE collections SET updated_at = now() WHERE id = $1`, id)
+		return err
+	})
+}
+
+func scanCollection(row pgx.Row) (domain.Collection, error) {
+	var collection domain.Collection
+	err := row.Scan(&collection.ID, &collection.Name, &collection.Description, &collection.MovieCount, &collection.CreatedAt, &collection.UpdatedAt)
+	if err != nil {
+		return domain.Collection{}, err
+	}
+	return collection, nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// exportBatchSize is the number of rows fetched from the export cursor per
+// round trip.
+const exportBatchSize = 500
+
+// Export streams every movie matching filters, in the order Sort and Order
+// ask for as in List, except that the default order is oldest first, together
+// with its rating aggregate from movie_rating_stats. Rows are read in batches
+// through a server-side cursor inside a read-only snapshot, so memory stays
+// flat however large the catalog is. Cursor and Limit are ignored. An error
+// returned by fn stops the export.
+func (r *MoviesRepository) Export(ctx context.Context, filters MovieListFilters, fn func(domain.Movie, domain.RatingAggregate) error) error {
+	args := make([]interface{}, 0)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := movieFilterConditions(filters, arg)
+	key, err := movieOrder(filters, arg)
+	if err != nil {
+		return err
+	}
+	if filters.Sort == MovieSortCreated && filters.Order == SortOrderDefault {
+		key.desc = false
+	}
+
+	query := strings.Builder{}
+	query.WriteString("DECLARE movie_export NO SCROLL CURSOR FOR SELECT ")
+	query.WriteString(movieColumns)
+	// Aggregates come from the trigger-maintained stats, as in List, rather
+	// than from rescanning every movie's ratings.
+	query.WriteString(`, COALESCE(ROUND(rs.rating_average, 1), 0)::float4, COALESCE(rs.rating_count, 0)
+        FROM movies LEFT JOIN movie_rating_stats rs ON rs.movie_id = movies.id`)
+	if len(where) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(where, " AND "))
+	}
+	query.WriteString(" ORDER BY ")
+	query.WriteString(key.orderBy())
+
+	txOpts := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	return pgx.BeginTxFunc(ctx, r.pool, txOpts, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, query.String(), args...); err != nil {
+			return err
+		}
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM movie_export", exportBatchSize)
+		for {
+			n, err := exportBatch(ctx, tx, fetch, fn)
+			if err != nil {
+				return err
+			}
+			if n < exportBatchSize {
+				return nil
+			}
+		}
+	})
+}
+
+// exportBatch fetches one batch from the export cursor, returning how many
+// rows it held.
+func exportBatch(ctx context.Context, tx pgx.Tx, fetch string, fn func(domain.Movie, domain.RatingAggregate) error) (int, error) {
+	rows, err := tx.Query(ctx, fetch)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var agg domain.RatingAggregate
+		movie, err := scanMovie(rows, &agg.Average, &agg.Count)
+		if err != nil {
+			return n, err
+		}
+		n++
+		if err := fn(movie, agg); err != nil {
+			return n, err
+		}
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MovieFacet names a field that matching movies can be counted by.
+type MovieFacet string
+
+// Supported facets.
+const (
+	MovieFacetGenre       MovieFacet = "genre"
+	MovieFacetYear        MovieFacet = "year"
+	MovieFacetDistributor MovieFacet = "distributor"
+	MovieFacetMpaRating   MovieFacet = "mpaRating"
+)
+
+// FacetBucketLimit caps the buckets returned per facet; the most frequent
+// values are kept.
+const FacetBucketLimit = 20
+
+// movieFacetQueries count the movies in the matched CTE per facet value.
+// Movies without a value are left out; a movie counts once for each of its
+// genres. Distributors are grouped case-insensitively, as the distributor
+// filter matches them, and reported in their most common spelling.
+var movieFacetQueries = map[MovieFacet]string{
+	MovieFacetGenre: `SELECT 'genre', g.name, COUNT(*) FROM matched
+        JOIN movie_genres mg ON mg.movie_id = matched.id
+        JOIN genres g ON g.id = mg.genre_id
+        GROUP BY g.name`,
+	MovieFacetYear: `SELECT 'year', release_year::text, COUNT(*) FROM matched GROUP BY release_year`,
+	MovieFacetDistributor: `SELECT 'distributor', mode() WITHIN GROUP (ORDER BY distributor), COUNT(*) FROM matched
+        WHERE distributor IS NOT NULL GROUP BY lower(distributor)`,
+	MovieFacetMpaRating: `SELECT 'mpaRating', mpa_rating, COUNT(*) FROM matched WHERE mpa_rating IS NOT NULL GROUP BY mpa_rating`,
+}
+
+// FacetBucket is the number of matching movies sharing one facet value.
+type FacetBucket struct {
+	Value string
+	Count int64
+}
+
+// MovieFacetCounts summarizes the movies matching a listing.
+type MovieFacetCounts struct {
+	Total int64
+	// Facets holds the buckets of each requested facet, most frequent first
+	// and at most FacetBucketLimit of them.
+	Facets map[MovieFacet][]FacetBucket
+}
+
+// Facets counts the movies matching filters, in total and per value of each
+// requested facet. It applies the same conditions as List but ignores sort
+// and pagination, so the counts cover every page.
+func (r *MoviesRepository) Facets(ctx context.Context, filters MovieListFilters, facets []MovieFacet) (MovieFacetCounts, error) {
+	args := make([]interface{}, 0)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := strings.Builder{}
+	query.WriteString("WITH matched AS MATERIALIZED (SELECT id, release_year, distributor, mpa_rating FROM movies")
+	if where := movieFilterConditions(filters, arg); len(where) > 0 {
+		query.WriteString(" WHERE ")
+		query.WriteString(strings.Join(where, " AND "))
+	}
+	query.WriteString(")\nSELECT ''::text, NULL::text, COUNT(*) FROM matched")
+	counts := MovieFacetCounts{Facets: make(map[MovieFacet][]FacetBucket, len(facets))}
+	for _, facet := range facets {
+		branch, ok := movieFacetQueries[facet]
+		if !ok {
+			return MovieFacetCounts{}, fmt.Errorf("repository: unknown facet %q", facet)
+		}
+		if _, seen := counts.Facets[facet]; seen {
+			continue
+		}
+		counts.Facets[facet] = []FacetBucket{}
+		fmt.Fprintf(&query, "\nUNION ALL (%s ORDER BY 3 DESC, 2 LIMIT %d)", branch, FacetBucketLimit)
+	}
+	query.WriteString("\nORDER BY 1, 3 DESC, 2")
+
+	rows, err := r.pool.Query(ctx, query.String(), args...)
+	if err != nil {
+		return MovieFacetCounts{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			facet  string
+			value  *string
+			bucket FacetBucket
+		)
+		if err := rows.Scan(&facet, &value, &bucket.Count); err != nil {
+			return MovieFacetCounts{}, err
+		}
+		if facet == "" {
+			counts.Total = bucket.Count
+			continue
+		}
+		bucket.Value = *value
+		counts.Facets[MovieFacet(facet)] = append(counts.Facets[MovieFacet(facet)], bucket)
+	}
+	return counts, rows.Err()
+}
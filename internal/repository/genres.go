@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// ErrUnknownGenre indicates a movie without genres, or a genre name that
+// cannot be resolved or created because it has no letters or digits.
+var ErrUnknownGenre = errors.New("repository: unknown genre")
+
+// GenresRepository manages the genre taxonomy.
+type GenresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// genresColumn aggregates a movie's canonical genre names, primary first.
+const genresColumn = `(
+        SELECT COALESCE(json_agg(g.name ORDER BY mg.position), '[]'::json)
+        FROM movie_genres mg
+        JOIN genres g ON g.id = mg.genre_id
+        WHERE mg.movie_id = movies.id
+    )`
+
+// genreMatch matches movies tagged with the genre whose synonym key equals
+// the placeholder it is formatted with.
+const genreMatch = `EXISTS (
+        SELECT 1 FROM movie_genres mg
+        JOIN genre_synonyms gs ON gs.genre_id = mg.genre_id
+        WHERE mg.movie_id = movies.id AND gs.key = %s
+    )`
+
+// GenreKey normalizes a genre spelling for synonym lookup: lower case with
+// everything but letters and digits removed.
+func GenreKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// List returns the whole taxonomy ordered by name.
+func (r *GenresRepository) List(ctx context.Context) ([]domain.Genre, error) {
+	rows, err := r.pool.Query(ctx, `
+        SELECT g.id, g.name,
+               COALESCE(array_agg(s.key ORDER BY s.key) FILTER (WHERE s.key IS NOT NULL), '{}')
+        FROM genres g
+        LEFT JOIN genre_synonyms s ON s.genre_id = g.id
+        GROUP BY g.id, g.name
+        ORDER BY lower(g.name)
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := make([]domain.Genre, 0)
+	for rows.Next() {
+		var genre domain.Genre
+		if err := rows.Scan(&genre.ID, &genre.Name, &genre.Synonyms); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+// Create adds a canonical genre with optional synonyms. It returns
+// ErrConflict when the name or a synonym already resolves to a genre.
+func (r *GenresRepository) Create(ctx context.Context, name string, synonyms []string) (domain.Genre, error) {
+	genre := domain.Genre{Name: name, Synonyms: make([]string, 0, len(synonyms)+1)}
+	seen := make(map[string]struct{})
+	for _, spelling := range append([]string{name}, synonyms...) {
+		key := GenreKey(spelling)
+		if key == "" {
+			return domain.Genre{}, fmt.Errorf("genre %q has no letters or digits", spelling)
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		genre.Synonyms = append(genre.Synonyms, key)
+	}
+
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, `INSERT INTO genres (name) VALUES ($1) RETURNING id`, name).Scan(&genre.ID); err != nil {
+			return err
+		}
+		for _, key := range genre.Synonyms {
+			if _, err := tx.Exec(ctx, `INSERT INTO genre_synonyms (key, genre_id) VALUES ($1, $2)`, key, genre.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Genre{}, translateConstraintError(err)
+	}
+	return genre, nil
+}
+
+type genreRef struct {
+	ID   int
+	Name string
+}
+
+// resolveGenres maps names to canonical genres, dropping duplicates while
+// keeping the first occurrence's position. Names that match no genre or
+// synonym become genres of their own, as the free-text genres in use before
+// the taxonomy did.
+func resolveGenres(ctx context.Context, tx pgx.Tx, names []string) ([]genreRef, error) {
+	refs := make([]genreRef, 0, len(names))
+	seen := make(map[int]struct{}, len(names))
+	for _, name := range names {
+		ref, err := resolveGenre(ctx, tx, name)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := seen[ref.ID]; dup {
+			continue
+		}
+		seen[ref.ID] = struct{}{}
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("%w: at least one genre is required", ErrUnknownGenre)
+	}
+	return refs, nil
+}
+
+// resolveGenre looks a name up by its synonym key, creating the genre when
+// nothing matches. A concurrent transaction creating the same genre makes the
+// inserts no-ops once it commits, and the final lookup then finds its row.
+func resolveGenre(ctx context.Context, tx pgx.Tx, name string) (genreRef, error) {
+	name = strings.TrimSpace(name)
+	key := GenreKey(name)
+	if key == "" {
+		return genreRef{}, fmt.Errorf("%w: %q has no letters or digits", ErrUnknownGenre, name)
+	}
+	lookup := func() (genreRef, error) {
+		var ref genreRef
+		err := tx.QueryRow(ctx, `
+            SELECT g.id, g.name FROM genre_synonyms s JOIN genres g ON g.id = s.genre_id WHERE s.key = $1
+        `, key).Scan(&ref.ID, &ref.Name)
+		return ref, err
+	}
+
+	ref, err := lookup()
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return ref, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO genres (name) VALUES ($1) ON CONFLICT DO NOTHING`, name); err != nil {
+		return genreRef{}, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO genre_synonyms (key, genre_id)
+        SELECT $1, id FROM genres WHERE lower(name) = lower($2)
+        ON CONFLICT DO NOTHING
+    `, key, name); err != nil {
+		return genreRef{}, err
+	}
+	ref, err = lookup()
+	if errors.Is(err, pgx.ErrNoRows) {
+		return genreRef{}, fmt.Errorf("%w: %q", ErrUnknownGenre, name)
+	}
+	return ref, err
+}
+
+// replaceGenres swaps the genres linked to a movie.
+func replaceGenres(ctx context.Context, tx pgx.Tx, movieID string, refs []genreRef) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM movie_genres WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+	for i, ref := range refs {
+		if _, err := tx.Exec(ctx, `INSERT INTO movie_genres (movie_id, genre_id, position) VALUES ($1, $2, $3)`, movieID, ref.ID, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genreNames combines the primary genre with additional ones, primary first.
+func genreNames(primary string, others []string) []string {
+	names := make([]string, 0, len(others)+1)
+	if strings.TrimSpace(primary) != "" {
+		names = append(names, primary)
+	}
+	return append(names, others...)
+}
+
+// primaryGenre is the genre stored in movies.genre: the client's own spelling
+// of the first name, which resolveGenres has checked to exist.
+func primaryGenre(names []string) string {
+	return strings.TrimSpace(names[0])
+}
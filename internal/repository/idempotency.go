@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository stores the responses to requests sent with an
+// Idempotency-Key header so retries can be answered without repeating the
+// write.
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// IdempotencyRecord is a claimed key. StatusCode is zero while the request
+// that claimed it is still being processed. ClaimedAt identifies the claim, so
+// a request whose claim was taken over cannot complete or release the key.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ClaimedAt   time.Time
+}
+
+// Begin claims key within scope for a request whose fingerprint is
+// requestHash. Keys created before expiredBefore count as unused and are taken
+// over, as are in-flight claims of the same request made before staleBefore,
+// whose request presumably died without completing or releasing the key. When
+// the key is held by an earlier request, its record is returned with claimed
+// false.
+func (r *IdempotencyRepository) Begin(ctx context.Context, scope, key, requestHash string, expiredBefore, staleBefore time.Time) (IdempotencyRecord, bool, error) {
+	const claim = `
+        INSERT INTO idempotency_keys (scope, key, request_hash)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (scope, key) DO UPDATE
+            SET request_hash = EXCLUDED.request_hash,
+                status_code = NULL,
+                response_headers = NULL,
+                response_body = NULL,
+                created_at = now(),
+                claimed_at = now()
+            WHERE idempotency_keys.created_at < $4
+               OR (idempotency_keys.status_code IS NULL
+                   AND idempotency_keys.request_hash = EXCLUDED.request_hash
+                   AND idempotency_keys.claimed_at < $5)
+        RETURNING created_at, claimed_at
+    `
+	const existing = `
+        SELECT request_hash, status_code, response_headers, response_body, created_at, claimed_at
+        FROM idempotency_keys
+        WHERE scope = $1 AND key = $2
+    `
+
+	// A held key can be released between the two statements; claim again then.
+	for attempt := 0; ; attempt++ {
+		record := IdempotencyRecord{Scope: scope, Key: key, RequestHash: requestHash}
+		err := r.pool.QueryRow(ctx, claim, scope, key, requestHash, expiredBefore, staleBefore).Scan(&record.CreatedAt, &record.ClaimedAt)
+		if err == nil {
+			return record, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return IdempotencyRecord{}, false, err
+		}
+
+		var (
+			status      *int
+			headersJSON []byte
+		)
+		err = r.pool.QueryRow(ctx, existing, scope, key).Scan(&record.RequestHash, &status, &headersJSON, &record.Body, &record.CreatedAt, &record.ClaimedAt)
+		if errors.Is(err, pgx.ErrNoRows) && attempt == 0 {
+			continue
+		}
+		if err != nil {
+			return IdempotencyRecord{}, false, err
+		}
+		if status != nil {
+			record.StatusCode = *status
+		}
+		if len(headersJSON) > 0 {
+			if err := json.Unmarshal(headersJSON, &record.Headers); err != nil {
+				return IdempotencyRecord{}, false, err
+			}
+		}
+		return record, false, nil
+	}
+}
+
+// Complete stores the response of the request holding the claim made at
+// claimedAt so later retries replay it. It returns ErrNotFound when the claim
+// has been taken over meanwhile.
+func (r *IdempotencyRepository) Complete(ctx context.Context, scope, key string, claimedAt time.Time, status int, headers map[string]string, body []byte) error {
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	tag, err := r.pool.Exec(ctx, `
+        UPDATE idempotency_keys
+        SET status_code = $4, response_headers = $5, response_body = $6
+        WHERE scope = $1 AND key = $2 AND claimed_at = $3 AND status_code IS NULL`,
+		scope, key, claimedAt, status, headersJSON, body)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Release forgets the claim made at claimedAt so the request may be retried,
+// e.g. after a failure that wrote nothing. A claim taken over meanwhile is
+// left alone.
+func (r *IdempotencyRepository) Release(ctx context.Context, scope, key string, claimedAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+        DELETE FROM idempotency_keys
+        WHERE scope = $1 AND key = $2 AND claimed_at = $3 AND status_code IS NULL`,
+		scope, key, claimedAt)
+	return err
+}
+
+// PurgeExpired removes keys created before the cutoff and returns the number
+// of rows removed.
+func (r *IdempotencyRepository) PurgeExpired(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -19,56 +21,304 @@ type MoviesRepository struct {
 	pool *pgxpool.Pool
 }
 
-const movieColumns = `
-    id,
-    title,
-    release_date,
-    release_year,
-    genre,
-    distributor,
-    budget,
-    mpa_rating,
-    box_office,
-    created_at,
-    updated_at
-`
-
-// MovieCreateParams bundles the fields required to create a movie.
+// MovieField is an optional part of a movie that List can skip loading. The
+// identity, title, dates, primary genre and slug are always loaded.
+type MovieField string
+
+// Optional movie fields, named like their JSON representation.
+const (
+	MovieFieldDistributor         MovieField = "distributor"
+	MovieFieldBudget              MovieField = "budget"
+	MovieFieldMpaRating           MovieField = "mpaRating"
+	MovieFieldBoxOffice           MovieField = "boxOffice"
+	MovieFieldRuntimeMinutes      MovieField = "runtimeMinutes"
+	MovieFieldOriginalLanguage    MovieField = "originalLanguage"
+	MovieFieldSpokenLanguages     MovieField = "spokenLanguages"
+	MovieFieldProductionCountries MovieField = "productionCountries"
+	MovieFieldSynopsis            MovieField = "synopsis"
+	MovieFieldPosterURL           MovieField = "posterUrl"
+	MovieFieldAlternateTitles     MovieField = "alternateTitles"
+	MovieFieldGenres              MovieField = "genres"
+	MovieFieldCertifications      MovieField = "certifications"
+	MovieFieldReleases            MovieField = "releases"
+)
+
+// movieScan receives the columns of a movie row; JSON columns are decoded
+// once the row has been scanned.
+type movieScan struct {
+	movie           domain.Movie
+	boxOffice       []byte
+	alternateTitles []byte
+	genres          []byte
+	certifications  []byte
+	releases        []byte
+}
+
+// movieColumn is one column of a movie read. Columns with a field are only
+// selected when that field is wanted.
+type movieColumn struct {
+	field MovieField
+	sql   string
+	dest  func(*movieScan) interface{}
+}
+
+var movieColumnList = []movieColumn{
+	{sql: "id", dest: func(m *movieScan) interface{} { return &m.movie.ID }},
+	{sql: "title", dest: func(m *movieScan) interface{} { return &m.movie.Title }},
+	{sql: "release_date", dest: func(m *movieScan) interface{} { return &m.movie.ReleaseDate }},
+	{sql: "release_year", dest: func(m *movieScan) interface{} { return &m.movie.ReleaseYear }},
+	{sql: "genre", dest: func(m *movieScan) interface{} { return &m.movie.Genre }},
+	{MovieFieldDistributor, "distributor", func(m *movieScan) interface{} { return &m.movie.Distributor }},
+	{MovieFieldBudget, "budget", func(m *movieScan) interface{} { return &m.movie.Budget }},
+	{MovieFieldMpaRating, "mpa_rating", func(m *movieScan) interface{} { return &m.movie.MpaRating }},
+	{MovieFieldBoxOffice, "box_office", func(m *movieScan) interface{} { return &m.boxOffice }},
+	{sql: "created_at", dest: func(m *movieScan) interface{} { return &m.movie.CreatedAt }},
+	{sql: "updated_at", dest: func(m *movieScan) interface{} { return &m.movie.UpdatedAt }},
+	{sql: "deleted_at", dest: func(m *movieScan) interface{} { return &m.movie.DeletedAt }},
+	{sql: "slug", dest: func(m *movieScan) interface{} { return &m.movie.Slug }},
+	{MovieFieldRuntimeMinutes, "runtime_minutes", func(m *movieScan) interface{} { return &m.movie.RuntimeMinutes }},
+	{MovieFieldOriginalLanguage, "original_language", func(m *movieScan) interface{} { return &m.movie.OriginalLanguage }},
+	{MovieFieldSpokenLanguages, "spoken_languages", func(m *movieScan) interface{} { return &m.movie.SpokenLanguages }},
+	{MovieFieldProductionCountries, "production_countries", func(m *movieScan) interface{} { return &m.movie.ProductionCountries }},
+	{MovieFieldSynopsis, "synopsis", func(m *movieScan) interface{} { return &m.movie.Synopsis }},
+	{MovieFieldPosterURL, "poster_url", func(m *movieScan) interface{} { return &m.movie.PosterURL }},
+	{MovieFieldAlternateTitles, alternateTitlesColumn, func(m *movieScan) interface{} { return &m.alternateTitles }},
+	{MovieFieldGenres, genresColumn, func(m *movieScan) interface{} { return &m.genres }},
+	{MovieFieldCertifications, certificationsColumn, func(m *movieScan) interface{} { return &m.certifications }},
+	{MovieFieldReleases, releasesColumn, func(m *movieScan) interface{} { return &m.releases }},
+}
+
+// movieColumns selects every column of a movie, for scanMovie.
+var movieColumns = selectMovieColumns(nil)
+
+// movieFieldSet is the set of optional fields to load; nil loads them all.
+type movieFieldSet map[MovieField]bool
+
+func newMovieFieldSet(fields []MovieField) movieFieldSet {
+	if fields == nil {
+		return nil
+	}
+	set := make(movieFieldSet, len(fields))
+	for _, field := range fields {
+		set[field] = true
+	}
+	return set
+}
+
+func (set movieFieldSet) selects(column movieColumn) bool {
+	return set == nil || column.field == "" || set[column.field]
+}
+
+// selectMovieColumns renders the select list for the columns in fields, in
+// the order scanMovieFields expects them.
+func selectMovieColumns(fields movieFieldSet) string {
+	columns := make([]string, 0, len(movieColumnList))
+	for _, column := range movieColumnList {
+		if fields.selects(column) {
+			columns = append(columns, column.sql)
+		}
+	}
+	return strings.Join(columns, ",\n    ")
+}
+
+// MovieCreateParams bundles the fields required to create a movie. Genre is
+// the primary genre and Genres lists further ones; names are resolved against
+// the genre taxonomy and at least one is required.
 type MovieCreateParams struct {
 	Title       string
 	ReleaseDate time.Time
 	Genre       string
+	Genres      []string
 	Distributor *string
 	Budget      *int64
 	MpaRating   *string
 	BoxOffice   *domain.BoxOffice
+
+	Metadata        MovieMetadata
+	AlternateTitles []domain.AlternateTitle
+	Certifications  []domain.Certification
+	Releases        []domain.Release
+}
+
+// MovieMetadata groups the descriptive fields shared by create and update.
+// Codes are expected to be validated and normalized by the caller.
+type MovieMetadata struct {
+	RuntimeMinutes      *int
+	OriginalLanguage    *string
+	SpokenLanguages     []string
+	ProductionCountries []string
+	Synopsis            *string
+	PosterURL           *string
+}
+
+// MovieUpdateParams carries the full set of editable fields; nil optional
+// fields are stored as NULL. Genre and Genres behave as in MovieCreateParams.
+// When ExpectedUpdatedAt is set the update only applies if the row has not
+// been modified since that timestamp.
+type MovieUpdateParams struct {
+	Title             string
+	ReleaseDate       time.Time
+	Genre             string
+	Genres            []string
+	Distributor       *string
+	Budget            *int64
+	MpaRating         *string
+	Metadata          MovieMetadata
+	AlternateTitles   []domain.AlternateTitle
+	Certifications    []domain.Certification
+	Releases          []domain.Release
+	ExpectedUpdatedAt *time.Time
+}
+
+// DeletedFilter selects how tombstoned movies are treated when listing.
+type DeletedFilter string
+
+const (
+	// DeletedExclude hides soft-deleted movies (default).
+	DeletedExclude DeletedFilter = ""
+	// DeletedOnly lists the trash.
+	DeletedOnly DeletedFilter = "only"
+	// DeletedInclude lists live and soft-deleted movies together.
+	DeletedInclude DeletedFilter = "include"
+)
+
+// GenreMatch selects how several genre filters combine.
+type GenreMatch string
+
+const (
+	// GenreMatchAny lists movies tagged with at least one of the genres (default).
+	GenreMatchAny GenreMatch = "any"
+	// GenreMatchAll lists movies tagged with every one of the genres.
+	GenreMatchAll GenreMatch = "all"
+)
+
+// MovieSort selects the order of a movie listing.
+type MovieSort string
+
+const (
+	// MovieSortCreated lists movies by creation time, newest first (default).
+	MovieSortCreated MovieSort = ""
+	// MovieSortRelevance ranks movies by how well they match Query, best first.
+	MovieSortRelevance MovieSort = "relevance"
+	// MovieSortReleaseDate lists movies by primary release date, latest first.
+	MovieSortReleaseDate MovieSort = "releaseDate"
+	// MovieSortTitle lists movies alphabetically, ignoring case.
+	MovieSortTitle MovieSort = "title"
+	// MovieSortBudget lists movies by budget, largest first.
+	MovieSortBudget MovieSort = "budget"
+	// MovieSortRevenue lists movies by worldwide box office revenue, largest
+	// first.
+	MovieSortRevenue MovieSort = "revenue"
+	// MovieSortRating lists movies by average rating, highest first.
+	MovieSortRating MovieSort = "rating"
+)
+
+// SortOrder overrides the direction of a MovieSort.
+type SortOrder string
+
+const (
+	// SortOrderDefault uses the natural direction of the sort.
+	SortOrderDefault SortOrder = ""
+	// SortOrderAsc lists the smallest, earliest or first-in-alphabet values first.
+	SortOrderAsc SortOrder = "asc"
+	// SortOrderDesc lists the largest, latest or last-in-alphabet values first.
+	SortOrderDesc SortOrder = "desc"
+)
+
+// worldwideRevenue extracts the worldwide box office revenue of a movie.
+const worldwideRevenue = `(box_office #>> '{revenue,worldwide}')::bigint`
+
+// movieSortKey describes how a MovieSort orders movies. Ties are broken by id
+// in the same direction. Movies without a value for a nullable key come last
+// in either direction.
+type movieSortKey struct {
+	expr     string
+	sqlType  string
+	nullable bool
+	desc     bool
+}
+
+var movieSortKeys = map[MovieSort]movieSortKey{
+	MovieSortCreated:     {expr: "created_at", sqlType: "timestamptz", desc: true},
+	MovieSortReleaseDate: {expr: "release_date", sqlType: "date", desc: true},
+	MovieSortTitle:       {expr: "lower(title)", sqlType: "text"},
+	MovieSortBudget:      {expr: "budget", sqlType: "bigint", nullable: true, desc: true},
+	MovieSortRevenue:     {expr: worldwideRevenue, sqlType: "bigint", nullable: true, desc: true},
+	MovieSortRating:      {expr: "rs.rating_average", sqlType: "numeric", nullable: true, desc: true},
 }
 
-// MovieListFilters encapsulates search and pagination options.
+// MovieListFilters encapsulates search and pagination options. Genres are
+// matched through the taxonomy, so synonyms such as "sci-fi" work. Person
+// matches a person id or exact name, optionally restricted to PersonRole;
+// Collection likewise matches a collection id or exact name. Languages match
+// the original or any spoken language and Countries any production country;
+// several values combine with OR, as do Certifications (country plus
+// canonical rating). MpaRating expects the canonical spelling. ReleasedAfter
+// and ReleasedBefore are inclusive and apply to releases in ReleasedIn when
+// set, otherwise to the primary release date. YearFrom/YearTo, BudgetMin/
+// BudgetMax and RevenueMin/RevenueMax (worldwide box office) are inclusive
+// bounds; movies without a budget or revenue never match those. MinRating
+// applies to the average rounded to one decimal, as displayed, and MinVotes
+// to the number of ratings. Query matches the full-text
+// search document (title, alternate titles, distributor, genres), similar
+// titles and substrings of titles and distributors; MovieSortRelevance
+// requires it. Sort and Order choose the listing order. Fields limits the
+// optional fields loaded for each movie; nil loads all of them.
 type MovieListFilters struct {
-	Query       *string
-	Year        *int
-	Genre       *string
-	Distributor *string
-	BudgetLTE   *int64
-	MpaRating   *string
-	Limit       int
-	Cursor      *MovieCursor
+	Query          *string
+	Year           *int
+	YearFrom       *int
+	YearTo         *int
+	Genres         []string
+	GenreMatch     GenreMatch
+	Person         *string
+	PersonRole     *domain.CreditRole
+	Collection     *string
+	Languages      []string
+	Countries      []string
+	Certifications []domain.Certification
+	ReleasedIn     []string
+	ReleasedAfter  *time.Time
+	ReleasedBefore *time.Time
+	Distributor    *string
+	BudgetMin      *int64
+	BudgetMax      *int64
+	RevenueMin     *int64
+	RevenueMax     *int64
+	MinRating      *float64
+	MinVotes       *int64
+	MpaRating      *string
+	Deleted        DeletedFilter
+	Sort           MovieSort
+	Order          SortOrder
+	Fields         []MovieField
+	Limit          int
+	Cursor         *MovieCursor
 }
 
-// MovieCursor allows stable pagination by created_at/id.
+// MovieCursor allows stable keyset pagination in any sort order. Value is
+// the text form of the sort key of the last movie on the page (nil when it
+// has none) and Filter fingerprints the filters and order the cursor was
+// issued for, so it cannot be replayed against a different listing.
 type MovieCursor struct {
-	CreatedAt time.Time `json:"createdAt"`
-	ID        string    `json:"id"`
+	Sort   MovieSort `json:"sort,omitempty"`
+	Filter string    `json:"filter,omitempty"`
+	Value  *string   `json:"value,omitempty"`
+	ID     string    `json:"id"`
 }
 
-// MovieListResult returns the paginated payload.
+// MovieListResult returns the paginated payload. Ratings holds the rating
+// aggregate of every item, keyed by movie ID.
 type MovieListResult struct {
 	Items      []domain.Movie
+	Ratings    map[string]domain.RatingAggregate
 	NextCursor *string
 }
 
-// Create inserts a new movie row and returns the stored entity.
+// Create inserts a new movie row and returns the stored entity. The movie is
+// given a slug derived from its title and release year. Constraint violations
+// are reported as a *ConstraintError; a live movie with the same title and
+// release year yields one matching ErrConflict.
 func (r *MoviesRepository) Create(ctx context.Context, params MovieCreateParams) (domain.Movie, error) {
 	boxOfficeJSON, err := marshalBoxOffice(params.BoxOffice)
 	if err != nil {
@@ -76,19 +326,146 @@ func (r *MoviesRepository) Create(ctx context.Context, params MovieCreateParams)
 	}
 
 	query := fmt.Sprintf(`
-        INSERT INTO movies (title, release_date, genre, distributor, budget, mpa_rating, box_office)
-        VALUES ($1,$2,$3,$4,$5,$6,$7)
+        INSERT INTO movies (title, release_date, genre, distributor, budget, mpa_rating, box_office, slug,
+                            runtime_minutes, original_language, spoken_languages, production_countries, synopsis, poster_url)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
         RETURNING %s
     `, movieColumns)
 
-	row := r.pool.QueryRow(ctx, query, params.Title, params.ReleaseDate, params.Genre, params.Distributor, params.Budget, params.MpaRating, boxOfficeJSON)
-	return scanMovie(row)
+	var movie domain.Movie
+	create := func(tx pgx.Tx) error {
+		names := genreNames(params.Genre, params.Genres)
+		genres, err := resolveGenres(ctx, tx, names)
+		if err != nil {
+			return err
+		}
+		slug, err := nextFreeSlug(ctx, tx, baseSlug(params.Title, params.ReleaseDate.Year()), "")
+		if err != nil {
+			return err
+		}
+		meta := params.Metadata
+		row := tx.QueryRow(ctx, query, params.Title, params.ReleaseDate, primaryGenre(names), params.Distributor, params.Budget, params.MpaRating, boxOfficeJSON, slug,
+			meta.RuntimeMinutes, meta.OriginalLanguage, codesOrEmpty(meta.SpokenLanguages), codesOrEmpty(meta.ProductionCountries), meta.Synopsis, meta.PosterURL)
+		created, err := scanMovie(row)
+		if err != nil {
+			return err
+		}
+		if err := replaceGenres(ctx, tx, created.ID, genres); err != nil {
+			return err
+		}
+		if err := replaceTitles(ctx, tx, created.ID, params.AlternateTitles); err != nil {
+			return err
+		}
+		if err := replaceCertifications(ctx, tx, created.ID, params.Certifications); err != nil {
+			return err
+		}
+		if err := replaceReleases(ctx, tx, created.ID, params.Releases); err != nil {
+			return err
+		}
+		if movie, err = reloadMovie(ctx, tx, created.ID); err != nil {
+			return err
+		}
+		return insertRevision(ctx, tx, domain.RevisionSourceUser, nil, &movie)
+	}
+
+	// A concurrent create may claim the same slug between lookup and insert;
+	// retry a couple of times before giving up.
+	for attempt := 0; ; attempt++ {
+		err = pgx.BeginFunc(ctx, r.pool, create)
+		if err == nil || !isSlugViolation(err) || attempt == 2 {
+			break
+		}
+	}
+	if err != nil {
+		return domain.Movie{}, translateConstraintError(err)
+	}
+	return movie, nil
 }
 
-// GetByTitle fetches a movie by its unique title.
+// titleMatch matches a title case-insensitively, by its hyphenated slug form
+// as slugify builds it (e.g. "the-dark-knight", "amélie") or by any alternate
+// title, against the placeholder it is formatted with.
+const titleMatch = `(lower(title) = lower(%[1]s) OR trim(both '-' from regexp_replace(lower(title), '[^[:alnum:]]+', '-', 'g')) = lower(%[1]s) OR ` + alternateTitleMatch + `)`
+
+// GetByTitle fetches the live movie with the given title. It returns
+// ErrAmbiguous when several releases share the title; use FindByTitle to
+// disambiguate by year.
 func (r *MoviesRepository) GetByTitle(ctx context.Context, title string) (domain.Movie, error) {
-	query := fmt.Sprintf(`SELECT %s FROM movies WHERE title = $1`, movieColumns)
-	row := r.pool.QueryRow(ctx, query, title)
+	movies, err := r.FindByTitle(ctx, title, nil)
+	if err != nil {
+		return domain.Movie{}, err
+	}
+	switch len(movies) {
+	case 0:
+		return domain.Movie{}, ErrNotFound
+	case 1:
+		return movies[0], nil
+	default:
+		return domain.Movie{}, ErrAmbiguous
+	}
+}
+
+// FindByTitle returns the live movies matching title, optionally restricted to
+// a release year, newest release first.
+func (r *MoviesRepository) FindByTitle(ctx context.Context, title string, year *int) ([]domain.Movie, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM movies
+        WHERE %s
+          AND ($2::int IS NULL OR release_year = $2)
+          AND deleted_at IS NULL
+        ORDER BY release_date DESC, id
+    `, movieColumns, fmt.Sprintf(titleMatch, "$1"))
+
+	rows, err := r.pool.Query(ctx, query, title, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := make([]domain.Movie, 0, 1)
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// GetByTitleYear fetches the live movie holding title in the given release
+// year, compared exactly as the uq_movies_title_year index does. Imports use
+// it to detect rows that would collide with an existing movie.
+func (r *MoviesRepository) GetByTitleYear(ctx context.Context, title string, year int) (domain.Movie, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM movies
+        WHERE lower(title) = lower($1) AND release_year = $2 AND deleted_at IS NULL
+    `, movieColumns)
+	movie, err := scanMovie(r.pool.QueryRow(ctx, query, title, year))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return domain.Movie{}, ErrNotFound
+		}
+		return domain.Movie{}, err
+	}
+	return movie, nil
+}
+
+// GetDeletedByTitle fetches the most recently soft-deleted movie with the
+// given title or slug, optionally restricted to a release year.
+func (r *MoviesRepository) GetDeletedByTitle(ctx context.Context, title string, year *int) (domain.Movie, error) {
+	query := fmt.Sprintf(`
+        SELECT %s FROM movies
+        WHERE (lower(title) = lower($1) OR slug = $1)
+          AND ($2::int IS NULL OR release_year = $2)
+          AND deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC
+        LIMIT 1
+    `, movieColumns)
+	row := r.pool.QueryRow(ctx, query, title, year)
 	movie, err := scanMovie(row)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -99,13 +476,13 @@ func (r *MoviesRepository) GetByTitle(ctx context.Context, title string) (domain
 	return movie, nil
 }
 
-// GetByID fetches a movie by its identifier.
+// GetByID fetches a live movie by its identifier.
 func (r *MoviesRepository) GetByID(ctx context.Context, id string) (domain.Movie, error) {
-	query := fmt.Sprintf(`SELECT %s FROM movies WHERE id = $1`, movieColumns)
+	query := fmt.Sprintf(`SELECT %s FROM movies WHERE id = $1 AND deleted_at IS NULL`, movieColumns)
 	row := r.pool.QueryRow(ctx, query, id)
 	movie, err := scanMovie(row)
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
 			return domain.Movie{}, ErrNotFound
 		}
 		return domain.Movie{}, err
@@ -114,7 +491,8 @@ func (r *MoviesRepository) GetByID(ctx context.Context, id string) (domain.Movie
 }
 
 // UpdateMetadata allows updating optional distributor/budget/mpaRating fields alongside box office payload.
-func (r *MoviesRepository) UpdateMetadata(ctx context.Context, id string, distributor *string, budget *int64, mpaRating *string, boxOffice *domain.BoxOffice) (domain.Movie, error) {
+// Releases are added unless the movie already has one for the same country and type.
+func (r *MoviesRepository) UpdateMetadata(ctx context.Context, id string, distributor *string, budget *int64, mpaRating *string, boxOffice *domain.BoxOffice, releases []domain.Release) (domain.Movie, error) {
 	boxOfficeJSON, err := marshalBoxOffice(boxOffice)
 	if err != nil {
 		return domain.Movie{}, err
@@ -131,10 +509,175 @@ func (r *MoviesRepository) UpdateMetadata(ctx context.Context, id string, distri
         RETURNING %s
     `, movieColumns)
 
-	row := r.pool.QueryRow(ctx, query, id, distributor, budget, mpaRating, boxOfficeJSON)
-	movie, err := scanMovie(row)
+	var movie domain.Movie
+	err = pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := lockMovie(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if _, err := scanMovie(tx.QueryRow(ctx, query, id, distributor, budget, mpaRating, boxOfficeJSON)); err != nil {
+			return err
+		}
+		if err := addReleases(ctx, tx, id, releases); err != nil {
+			return err
+		}
+		if movie, err = reloadMovie(ctx, tx, id); err != nil {
+			return err
+		}
+		return insertRevision(ctx, tx, domain.RevisionSourceBoxOffice, &before, &movie)
+	})
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		return domain.Movie{}, err
+	}
+	return movie, nil
+}
+
+// Update replaces the editable fields of a movie, leaving box office data
+// untouched. Changing the title or release year moves the movie to a new slug;
+// the old one keeps resolving through GetBySlug.
+func (r *MoviesRepository) Update(ctx context.Context, id string, params MovieUpdateParams) (domain.Movie, error) {
+	query := fmt.Sprintf(`
+        UPDATE movies
+        SET title = $2,
+            release_date = $3,
+            genre = $4,
+            distributor = $5,
+            budget = $6,
+            mpa_rating = $7,
+            slug = $8,
+            runtime_minutes = $9,
+            original_language = $10,
+            spoken_languages = $11,
+            production_countries = $12,
+            synopsis = $13,
+            poster_url = $14
+        WHERE id = $1
+        RETURNING %s
+    `, movieColumns)
+
+	var movie domain.Movie
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := lockMovie(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if params.ExpectedUpdatedAt != nil && !before.UpdatedAt.Equal(*params.ExpectedUpdatedAt) {
+			return ErrVersionMismatch
+		}
+		names := genreNames(params.Genre, params.Genres)
+		genres, err := resolveGenres(ctx, tx, names)
+		if err != nil {
+			return err
+		}
+		slug, err := reslug(ctx, tx, before, params.Title, params.ReleaseDate.Year())
+		if err != nil {
+			return err
+		}
+		meta := params.Metadata
+		row := tx.QueryRow(ctx, query, id, params.Title, params.ReleaseDate, primaryGenre(names), params.Distributor, params.Budget, params.MpaRating, slug,
+			meta.RuntimeMinutes, meta.OriginalLanguage, codesOrEmpty(meta.SpokenLanguages), codesOrEmpty(meta.ProductionCountries), meta.Synopsis, meta.PosterURL)
+		if _, err := scanMovie(row); err != nil {
+			return err
+		}
+		if err := replaceGenres(ctx, tx, id, genres); err != nil {
+			return err
+		}
+		if err := replaceTitles(ctx, tx, id, params.AlternateTitles); err != nil {
+			return err
+		}
+		if err := replaceCertifications(ctx, tx, id, params.Certifications); err != nil {
+			return err
+		}
+		if err := replaceReleases(ctx, tx, id, params.Releases); err != nil {
+			return err
+		}
+		if movie, err = reloadMovie(ctx, tx, id); err != nil {
+			return err
+		}
+		return insertRevision(ctx, tx, domain.RevisionSourceUser, &before, &movie)
+	})
+	if err != nil {
+		return domain.Movie{}, translateConstraintError(err)
+	}
+	return movie, nil
+}
+
+// Delete soft-deletes a movie so it can be restored until the trash is purged.
+// A non-nil expectedUpdatedAt guards against deleting a row modified concurrently.
+func (r *MoviesRepository) Delete(ctx context.Context, id string, expectedUpdatedAt *time.Time) error {
+	query := fmt.Sprintf(`
+        UPDATE movies
+        SET deleted_at = now()
+        WHERE id = $1
+        RETURNING %s
+    `, movieColumns)
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := lockMovie(ctx, tx, id, false)
+		if err != nil {
+			return err
+		}
+		if expectedUpdatedAt != nil && !before.UpdatedAt.Equal(*expectedUpdatedAt) {
+			return ErrVersionMismatch
+		}
+		deleted, err := scanMovie(tx.QueryRow(ctx, query, id))
+		if err != nil {
+			return err
+		}
+		return insertRevision(ctx, tx, domain.RevisionSourceUser, &before, &deleted)
+	})
+}
+
+// Restore brings a soft-deleted movie back. It fails with ErrConflict when a
+// live movie has taken the title in the meantime.
+func (r *MoviesRepository) Restore(ctx context.Context, id string) (domain.Movie, error) {
+	query := fmt.Sprintf(`
+        UPDATE movies
+        SET deleted_at = NULL
+        WHERE id = $1
+        RETURNING %s
+    `, movieColumns)
+
+	var movie domain.Movie
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		before, err := lockMovie(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		restored, err := scanMovie(tx.QueryRow(ctx, query, id))
+		if err != nil {
+			return err
+		}
+		movie = restored
+		return insertRevision(ctx, tx, domain.RevisionSourceUser, &before, &movie)
+	})
+	if err != nil {
+		return domain.Movie{}, translateConstraintError(err)
+	}
+	return movie, nil
+}
+
+// PurgeDeleted permanently removes movies soft-deleted before the cutoff,
+// cascading to their ratings, and returns the number of rows removed.
+func (r *MoviesRepository) PurgeDeleted(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM movies WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// lockMovie loads a movie with FOR UPDATE inside tx. It only matches live
+// movies unless deleted is true, in which case it only matches tombstoned ones.
+func lockMovie(ctx context.Context, tx pgx.Tx, id string, deleted bool) (domain.Movie, error) {
+	state := "deleted_at IS NULL"
+	if deleted {
+		state = "deleted_at IS NOT NULL"
+	}
+	query := fmt.Sprintf(`SELECT %s FROM movies WHERE id = $1 AND %s FOR UPDATE`, movieColumns, state)
+	movie, err := scanMovie(tx.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
 			return domain.Movie{}, ErrNotFound
 		}
 		return domain.Movie{}, err
@@ -142,59 +685,65 @@ func (r *MoviesRepository) UpdateMetadata(ctx context.Context, id string, distri
 	return movie, nil
 }
 
-// List returns movies that match the provided filters.
+// relevanceScore ranks a movie against the search text in the placeholder it
+// is formatted with: the full-text rank of its search document plus the
+// trigram similarity of its title, so near-misses still order sensibly.
+const relevanceScore = `(ts_rank(search_vector, websearch_to_tsquery('simple', %[1]s))::float8 + similarity(title, %[1]s)::float8)`
+
+// List returns movies that match the provided filters. It fails with
+// ErrInvalidCursor when the cursor was issued for another sort order or other
+// filters.
 func (r *MoviesRepository) List(ctx context.Context, filters MovieListFilters) (MovieListResult, error) {
 	if filters.Limit <= 0 {
 		filters.Limit = 20
 	} else if filters.Limit > 100 {
 		filters.Limit = 100
 	}
+	query := ""
+	if filters.Query != nil {
+		query = strings.TrimSpace(*filters.Query)
+	}
+	if filters.Sort == MovieSortRelevance && query == "" {
+		return MovieListResult{}, fmt.Errorf("repository: relevance sort requires a query")
+	}
+	fingerprint, err := FilterFingerprint(filters)
+	if err != nil {
+		return MovieListResult{}, err
+	}
+	if filters.Cursor != nil && (filters.Cursor.Sort != filters.Sort || filters.Cursor.Filter != fingerprint) {
+		return MovieListResult{}, ErrInvalidCursor
+	}
 
-	where := make([]string, 0)
 	args := make([]interface{}, 0)
 	arg := func(value interface{}) string {
 		args = append(args, value)
 		return fmt.Sprintf("$%d", len(args))
 	}
 
-	if filters.Query != nil && strings.TrimSpace(*filters.Query) != "" {
-		q := "%" + strings.TrimSpace(*filters.Query) + "%"
-		p1 := arg(q)
-		p2 := arg(q)
-		where = append(where, fmt.Sprintf("(title ILIKE %s OR distributor ILIKE %s)", p1, p2))
-	}
-	if filters.Year != nil {
-		where = append(where, fmt.Sprintf("release_year = %s", arg(*filters.Year)))
-	}
-	if filters.Genre != nil && strings.TrimSpace(*filters.Genre) != "" {
-		where = append(where, fmt.Sprintf("genre ILIKE %s", arg(strings.TrimSpace(*filters.Genre))))
-	}
-	if filters.Distributor != nil && strings.TrimSpace(*filters.Distributor) != "" {
-		where = append(where, fmt.Sprintf("distributor ILIKE %s", arg(strings.TrimSpace(*filters.Distributor))))
-	}
-	if filters.BudgetLTE != nil {
-		where = append(where, fmt.Sprintf("budget <= %s", arg(*filters.BudgetLTE)))
-	}
-	if filters.MpaRating != nil && strings.TrimSpace(*filters.MpaRating) != "" {
-		where = append(where, fmt.Sprintf("mpa_rating ILIKE %s", arg(strings.TrimSpace(*filters.MpaRating))))
+	where := movieFilterConditions(filters, arg)
+	key, err := movieOrder(filters, arg)
+	if err != nil {
+		return MovieListResult{}, err
 	}
 	if filters.Cursor != nil {
-		cursorCreated := arg(filters.Cursor.CreatedAt)
-		cursorID := arg(filters.Cursor.ID)
-		where = append(where, fmt.Sprintf("(created_at, id) < (%s, %s)", cursorCreated, cursorID))
+		where = append(where, key.after(filters.Cursor, arg))
 	}
 
 	queryBuilder := strings.Builder{}
 	queryBuilder.WriteString("SELECT ")
-	queryBuilder.WriteString(movieColumns)
-	queryBuilder.WriteString(" FROM movies")
+	fields := newMovieFieldSet(filters.Fields)
+	queryBuilder.WriteString(selectMovieColumns(fields))
+	queryBuilder.WriteString(`, COALESCE(ROUND(rs.rating_average, 1), 0)::float4, COALESCE(rs.rating_count, 0), (`)
+	queryBuilder.WriteString(key.expr)
+	queryBuilder.WriteString(")::text AS sort_value FROM movies LEFT JOIN movie_rating_stats rs ON rs.movie_id = movies.id")
 
 	if len(where) > 0 {
 		queryBuilder.WriteString(" WHERE ")
 		queryBuilder.WriteString(strings.Join(where, " AND "))
 	}
 
-	queryBuilder.WriteString(" ORDER BY created_at DESC, id DESC")
+	queryBuilder.WriteString(" ORDER BY ")
+	queryBuilder.WriteString(key.orderBy())
 	queryBuilder.WriteString(fmt.Sprintf(" LIMIT %d", filters.Limit))
 
 	rows, err := r.pool.Query(ctx, queryBuilder.String(), args...)
@@ -204,72 +753,250 @@ func (r *MoviesRepository) List(ctx context.Context, filters MovieListFilters) (
 	defer rows.Close()
 
 	items := make([]domain.Movie, 0)
+	ratings := make(map[string]domain.RatingAggregate)
+	var lastValue *string
 	for rows.Next() {
-		movie, err := scanMovie(rows)
+		var agg domain.RatingAggregate
+		movie, err := scanMovieFields(rows, fields, &agg.Average, &agg.Count, &lastValue)
 		if err != nil {
 			return MovieListResult{}, err
 		}
 		items = append(items, movie)
+		ratings[movie.ID] = agg
 	}
 	if err := rows.Err(); err != nil {
+		if filters.Cursor != nil && isInvalidTextRepresentation(err) {
+			return MovieListResult{}, ErrInvalidCursor
+		}
 		return MovieListResult{}, err
 	}
 
 	var nextCursor *string
 	if len(items) == filters.Limit {
 		last := items[len(items)-1]
-		cursor := MovieCursor{CreatedAt: last.CreatedAt, ID: last.ID}
-		token, err := encodeCursor(cursor)
+		token, err := encodeToken(MovieCursor{Sort: filters.Sort, Filter: fingerprint, Value: lastValue, ID: last.ID})
 		if err != nil {
 			return MovieListResult{}, err
 		}
 		nextCursor = &token
 	}
 
-	return MovieListResult{Items: items, NextCursor: nextCursor}, nil
-}
-
-func scanMovie(row pgx.Row) (domain.Movie, error) {
-	var (
-		movie         domain.Movie
-		releaseDate   time.Time
-		releaseYear   int
-		distributor   *string
-		budget        *int64
-		mpaRating     *string
-		boxOfficeJSON []byte
-		createdAt     time.Time
-		updatedAt     time.Time
-	)
-
-	err := row.Scan(
-		&movie.ID,
-		&movie.Title,
-		&releaseDate,
-		&releaseYear,
-		&movie.Genre,
-		&distributor,
-		&budget,
-		&mpaRating,
-		&boxOfficeJSON,
-		&createdAt,
-		&updatedAt,
-	)
+	return MovieListResult{Items: items, Ratings: ratings, NextCursor: nextCursor}, nil
+}
+
+// movieOrder resolves the sort key and direction filters ask for,
+// registering the search text of a relevance sort through arg.
+func movieOrder(filters MovieListFilters, arg func(interface{}) string) (movieSortKey, error) {
+	key, ok := movieSortKeys[filters.Sort]
+	if filters.Sort == MovieSortRelevance {
+		if filters.Query == nil || strings.TrimSpace(*filters.Query) == "" {
+			return movieSortKey{}, fmt.Errorf("repository: relevance sort requires a query")
+		}
+		key, ok = movieSortKey{expr: fmt.Sprintf(relevanceScore, arg(strings.TrimSpace(*filters.Query))), sqlType: "float8", desc: true}, true
+	}
+	if !ok {
+		return movieSortKey{}, fmt.Errorf("repository: unknown sort %q", filters.Sort)
+	}
+	switch filters.Order {
+	case SortOrderAsc:
+		key.desc = false
+	case SortOrderDesc:
+		key.desc = true
+	}
+	return key, nil
+}
+
+func (k movieSortKey) orderBy() string {
+	if k.desc {
+		return fmt.Sprintf("%s DESC NULLS LAST, id DESC", k.expr)
+	}
+	return fmt.Sprintf("%s ASC NULLS LAST, id ASC", k.expr)
+}
+
+// after renders the keyset condition selecting the movies that follow the
+// cursor in this order.
+func (k movieSortKey) after(cursor *MovieCursor, arg func(interface{}) string) string {
+	cmp := ">"
+	if k.desc {
+		cmp = "<"
+	}
+	id := arg(cursor.ID)
+	if cursor.Value == nil {
+		return fmt.Sprintf("(%s IS NULL AND id %s %s)", k.expr, cmp, id)
+	}
+	condition := fmt.Sprintf("(%s, id) %s (%s::%s, %s)", k.expr, cmp, arg(*cursor.Value), k.sqlType, id)
+	if k.nullable {
+		condition = fmt.Sprintf("(%s OR %s IS NULL)", condition, k.expr)
+	}
+	return condition
+}
+
+// FilterFingerprint identifies a listing by everything but its page; cursors
+// carry it so a page token only continues the listing it was issued for.
+func FilterFingerprint(filters MovieListFilters) (string, error) {
+	filters.Cursor, filters.Limit, filters.Fields = nil, 0, nil
+	payload, err := json.Marshal(filters)
 	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// movieFilterConditions renders every filter except pagination as SQL
+// conditions on movies, registering placeholder values through arg.
+func movieFilterConditions(filters MovieListFilters, arg func(interface{}) string) []string {
+	where := make([]string, 0)
+	switch filters.Deleted {
+	case DeletedOnly:
+		where = append(where, "deleted_at IS NOT NULL")
+	case DeletedInclude:
+	default:
+		where = append(where, "deleted_at IS NULL")
+	}
+	if filters.Query != nil && strings.TrimSpace(*filters.Query) != "" {
+		q := strings.TrimSpace(*filters.Query)
+		text, pattern := arg(q), arg("%"+q+"%")
+		where = append(where, fmt.Sprintf(
+			"(search_vector @@ websearch_to_tsquery('simple', %[1]s) OR title %% %[1]s OR title ILIKE %[2]s OR distributor ILIKE %[2]s OR EXISTS (SELECT 1 FROM movie_titles t WHERE t.movie_id = movies.id AND t.title ILIKE %[2]s))", text, pattern))
+	}
+	if filters.Year != nil {
+		where = append(where, fmt.Sprintf("release_year = %s", arg(*filters.Year)))
+	}
+	if filters.YearFrom != nil {
+		where = append(where, fmt.Sprintf("release_year >= %s", arg(*filters.YearFrom)))
+	}
+	if filters.YearTo != nil {
+		where = append(where, fmt.Sprintf("release_year <= %s", arg(*filters.YearTo)))
+	}
+	if len(filters.Genres) > 0 {
+		clauses := make([]string, 0, len(filters.Genres))
+		for _, genre := range filters.Genres {
+			clauses = append(clauses, fmt.Sprintf(genreMatch, arg(GenreKey(genre))))
+		}
+		joiner := " OR "
+		if filters.GenreMatch == GenreMatchAll {
+			joiner = " AND "
+		}
+		where = append(where, "("+strings.Join(clauses, joiner)+")")
+	}
+	if filters.Person != nil && strings.TrimSpace(*filters.Person) != "" {
+		person := arg(strings.TrimSpace(*filters.Person))
+		role := "NULL"
+		if filters.PersonRole != nil {
+			role = arg(string(*filters.PersonRole))
+		}
+		where = append(where, fmt.Sprintf(`EXISTS (
+            SELECT 1 FROM movie_credits c JOIN people p ON p.id = c.person_id
+            WHERE c.movie_id = movies.id
+              AND (p.id::text = %[1]s OR lower(p.name) = lower(%[1]s))
+              AND (%[2]s::text IS NULL OR c.role = %[2]s)
+        )`, person, role))
+	}
+	if filters.Collection != nil && strings.TrimSpace(*filters.Collection) != "" {
+		// Only values Postgres can cast are compared with the id, so the
+		// uuid index is used and names never fail the cast.
+		collection := strings.TrimSpace(*filters.Collection)
+		match := fmt.Sprintf("lower(col.name) = lower(%s)", arg(collection))
+		if isUUIDText(collection) {
+			match = fmt.Sprintf("(col.id = %s::uuid OR %s)", arg(collection), match)
+		}
+		where = append(where, fmt.Sprintf(`EXISTS (
+            SELECT 1 FROM collection_movies cm JOIN collections col ON col.id = cm.collection_id
+            WHERE cm.movie_id = movies.id AND %s
+        )`, match))
+	}
+	if len(filters.Languages) > 0 {
+		p := arg(filters.Languages)
+		where = append(where, fmt.Sprintf("(original_language = ANY(%[1]s) OR spoken_languages && %[1]s::text[])", p))
+	}
+	if len(filters.Countries) > 0 {
+		where = append(where, fmt.Sprintf("production_countries && %s::text[]", arg(filters.Countries)))
+	}
+	if filters.Distributor != nil && strings.TrimSpace(*filters.Distributor) != "" {
+		where = append(where, fmt.Sprintf("distributor ILIKE %s", arg(strings.TrimSpace(*filters.Distributor))))
+	}
+	if filters.BudgetMin != nil {
+		where = append(where, fmt.Sprintf("budget >= %s", arg(*filters.BudgetMin)))
+	}
+	if filters.BudgetMax != nil {
+		where = append(where, fmt.Sprintf("budget <= %s", arg(*filters.BudgetMax)))
+	}
+	if filters.RevenueMin != nil {
+		where = append(where, fmt.Sprintf("%s >= %s", worldwideRevenue, arg(*filters.RevenueMin)))
+	}
+	if filters.RevenueMax != nil {
+		where = append(where, fmt.Sprintf("%s <= %s", worldwideRevenue, arg(*filters.RevenueMax)))
+	}
+	if filters.MinRating != nil || (filters.MinVotes != nil && *filters.MinVotes > 0) {
+		conditions := []string{"st.movie_id = movies.id"}
+		if filters.MinRating != nil {
+			conditions = append(conditions, fmt.Sprintf("ROUND(st.rating_average, 1) >= %s", arg(*filters.MinRating)))
+		}
+		if filters.MinVotes != nil && *filters.MinVotes > 0 {
+			conditions = append(conditions, fmt.Sprintf("st.rating_count >= %s", arg(*filters.MinVotes)))
+		}
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM movie_rating_stats st WHERE %s)", strings.Join(conditions, " AND ")))
+	}
+	if filters.MpaRating != nil && strings.TrimSpace(*filters.MpaRating) != "" {
+		where = append(where, fmt.Sprintf("mpa_rating = %s", arg(strings.TrimSpace(*filters.MpaRating))))
+	}
+	if len(filters.Certifications) > 0 {
+		where = append(where, certificationCondition(filters.Certifications, arg))
+	}
+	if len(filters.ReleasedIn) > 0 || filters.ReleasedAfter != nil || filters.ReleasedBefore != nil {
+		where = append(where, releaseCondition(filters.ReleasedIn, filters.ReleasedAfter, filters.ReleasedBefore, arg))
+	}
+	return where
+}
+
+// scanMovie reads a row selected with movieColumns. Extra destinations are
+// scanned from any columns selected after them.
+func scanMovie(row pgx.Row, extra ...interface{}) (domain.Movie, error) {
+	return scanMovieFields(row, nil, extra...)
+}
+
+// scanMovieFields reads a row selected with selectMovieColumns(fields).
+// Fields that were not selected keep their zero value.
+func scanMovieFields(row pgx.Row, fields movieFieldSet, extra ...interface{}) (domain.Movie, error) {
+	var scan movieScan
+	dest := make([]interface{}, 0, len(movieColumnList)+len(extra))
+	for _, column := range movieColumnList {
+		if fields.selects(column) {
+			dest = append(dest, column.dest(&scan))
+		}
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return domain.Movie{}, err
 	}
 
-	movie.ReleaseDate = releaseDate
-	movie.ReleaseYear = releaseYear
-	movie.Distributor = distributor
-	movie.Budget = budget
-	movie.MpaRating = mpaRating
-	movie.CreatedAt = createdAt
-	movie.UpdatedAt = updatedAt
+	movie := scan.movie
+	decode := func(data []byte, dst interface{}) error {
+		if len(data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(data, dst)
+	}
 
-	if len(boxOfficeJSON) > 0 {
+	movie.AlternateTitles = make([]domain.AlternateTitle, 0)
+	if err := decode(scan.alternateTitles, &movie.AlternateTitles); err != nil {
+		return domain.Movie{}, err
+	}
+	movie.Genres = make([]string, 0, 1)
+	if err := decode(scan.genres, &movie.Genres); err != nil {
+		return domain.Movie{}, err
+	}
+	movie.Certifications = make([]domain.Certification, 0)
+	if err := decode(scan.certifications, &movie.Certifications); err != nil {
+		return domain.Movie{}, err
+	}
+	movie.Releases = make([]domain.Release, 0)
+	if err := decode(scan.releases, &movie.Releases); err != nil {
+		return domain.Movie{}, err
+	}
+	if len(scan.boxOffice) > 0 {
 		var box domain.BoxOffice
-		if err := json.Unmarshal(boxOfficeJSON, &box); err != nil {
+		if err := decode(scan.boxOffice, &box); err != nil {
 			return domain.Movie{}, err
 		}
 		movie.BoxOffice = &box
@@ -278,6 +1005,14 @@ func scanMovie(row pgx.Row) (domain.Movie, error) {
 	return movie, nil
 }
 
+// codesOrEmpty maps nil to an empty slice for NOT NULL array columns.
+func codesOrEmpty(codes []string) []string {
+	if codes == nil {
+		return []string{}
+	}
+	return codes
+}
+
 func marshalBoxOffice(boxOffice *domain.BoxOffice) ([]byte, error) {
 	if boxOffice == nil {
 		return nil, nil
@@ -285,26 +1020,34 @@ func marshalBoxOffice(boxOffice *domain.BoxOffice) ([]byte, error) {
 	return json.Marshal(boxOffice)
 }
 
-func encodeCursor(c MovieCursor) (string, error) {
-	payload, err := json.Marshal(c)
+// encodeToken serializes a cursor value into an opaque pagination token.
+func encodeToken(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
 	if err != nil {
 		return "", err
 	}
 	return base64.StdEncoding.EncodeToString(payload), nil
 }
 
+func decodeToken(token string, dst interface{}) error {
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("invalid cursor payload: %w", err)
+	}
+	return nil
+}
+
 // DecodeCursor parses a cursor token into a MovieCursor.
 func DecodeCursor(token string) (*MovieCursor, error) {
 	if token == "" {
 		return nil, nil
 	}
-	data, err := base64.StdEncoding.DecodeString(token)
-	if err != nil {
-		return nil, fmt.Errorf("invalid cursor: %w", err)
-	}
 	var cursor MovieCursor
-	if err := json.Unmarshal(data, &cursor); err != nil {
-		return nil, fmt.Errorf("invalid cursor payload: %w", err)
+	if err := decodeToken(token, &cursor); err != nil {
+		return nil, err
 	}
 	return &cursor, nil
 }
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// ErrUnknownPerson indicates a credit referencing a person that does not exist.
+var ErrUnknownPerson = errors.New("repository: unknown person")
+
+// PeopleRepository manages people and their movie credits.
+type PeopleRepository struct {
+	pool *pgxpool.Pool
+}
+
+const personColumns = `id, name, birth_date, biography, created_at, updated_at`
+
+// PersonParams carries the editable fields of a person; nil optional fields
+// are stored as NULL.
+type PersonParams struct {
+	Name      string
+	BirthDate *time.Time
+	Biography *string
+}
+
+// PeopleListFilters encapsulates search and pagination options.
+type PeopleListFilters struct {
+	Query  *string
+	Limit  int
+	Cursor *PersonCursor
+}
+
+// PersonCursor allows stable pagination by name/id.
+type PersonCursor struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// PeopleListResult returns the paginated payload.
+type PeopleListResult struct {
+	Items      []domain.Person
+	NextCursor *string
+}
+
+// CreditParams describes one credit when replacing a movie's credits.
+type CreditParams struct {
+	PersonID     string
+	Role         domain.CreditRole
+	Character    *string
+	BillingOrder int
+}
+
+// Create inserts a person.
+func (r *PeopleRepository) Create(ctx context.Context, params PersonParams) (domain.Person, error) {
+	query := fmt.Sprintf(`
+        INSERT INTO people (name, birth_date, biography)
+        VALUES ($1, $2, $3)
+        RETURNING %s
+    `, personColumns)
+	return scanPerson(r.pool.QueryRow(ctx, query, params.Name, params.BirthDate, params.Biography))
+}
+
+// GetByID fetches a person by identifier.
+func (r *PeopleRepository) GetByID(ctx context.Context, id string) (domain.Person, error) {
+	query := fmt.Sprintf(`SELECT %s FROM people WHERE id = $1`, personColumns)
+	person, err := scanPerson(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return domain.Person{}, ErrNotFound
+		}
+		return domain.Person{}, err
+	}
+	return person, nil
+}
+
+// Update replaces the editable fields of a person.
+func (r *PeopleRepository) Update(ctx context.Context, id string, params PersonParams) (domain.Person, error) {
+	query := fmt.Sprintf(`
+        UPDATE people
+        SET name = $2, birth_date = $3, biography = $4
+        WHERE id = $1
+        RETURNING %s
+    `, personColumns)
+	person, err := scanPerson(r.pool.QueryRow(ctx, query, id, params.Name, params.BirthDate, params.Biography))
+	if err != nil {
+		if err == pgx.ErrNoRows || isInvalidTextRepresentation(err) {
+			return domain.Person{}, ErrNotFound
+		}
+		return domain.Person{}, err
+	}
+	return person, nil
+}
+
+// Delete removes a person. It fails with ErrConstraint while the person still
+// has credits.
+func (r *PeopleRepository) Delete(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM people WHERE id = $1`, id)
+	if err != nil {
+		if isInvalidTextRepresentation(err) {
+			return ErrNotFound
+		}
+		return translateConstraintError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns people matching the filters ordered by name.
+func (r *PeopleRepository) List(ctx context.Context, filters PeopleListFilters) (PeopleListResult, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	} else if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	where := make([]string, 0)
+	args := make([]interface{}, 0)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filters.Query != nil && strings.TrimSpace(*filters.Query) != "" {
+		where = append(where, fmt.Sprintf("name ILIKE %s", arg("%"+strings.TrimSpace(*filters.Query)+"%")))
+	}
+	if filters.Cursor != nil {
+		where = append(where, fmt.Sprintf("(lower(name), id) > (lower(%s), %s)", arg(filters.Cursor.Name), arg(filters.Cursor.ID)))
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM people`, personColumns)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY lower(name), id LIMIT %d", filters.Limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return PeopleListResult{}, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.Person, 0)
+	for rows.Next() {
+		person, err := scanPerson(rows)
+		if err != nil {
+			return PeopleListResult{}, err
+		}
+		items = append(items, person)
+	}
+	if err := rows.Err(); err != nil {
+		return PeopleListResult{}, err
+	}
+
+	var nextCursor *string
+	if len(items) == filters.Limit {
+		last := items[len(items)-1]
+		token, err := encodeToken(PersonCursor{Name: last.Name, ID: last.ID})
+		if err != nil {
+			return PeopleListResult{}, err
+		}
+		nextCursor = &token
+	}
+	return PeopleListResult{Items: items, NextCursor: nextCursor}, nil
+}
+
+// DecodePersonCursor parses a cursor token into a PersonCursor.
+func DecodePersonCursor(token string) (*PersonCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var cursor PersonCursor
+	if err := decodeToken(token, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// CreditsByMovie lists a movie's credits in billing order.
+func (r *PeopleRepository) CreditsByMovie(ctx context.Context, movieID string) ([]domain.Credit, error) {
+	credits, err := r.CreditsByMovies(ctx, []string{movieID})
+	if err != nil {
+		return nil, err
+	}
+	if credits[movieID] == nil {
+		return make([]domain.Credit, 0), nil
+	}
+	return credits[movieID], nil
+}
+
+// CreditsByMovies loads the credits of several movies in one query, keyed by
+// movie ID and in billing order. Movies without credits have no entry.
+func (r *PeopleRepository) CreditsByMovies(ctx context.Context, movieIDs []string) (map[string][]domain.Credit, error) {
+	const query = `
+        SELECT c.id, c.movie_id, c.person_id, c.role, c.character, c.billing_order, p.name
+        FROM movie_credits c
+        JOIN people p ON p.id = c.person_id
+        WHERE c.movie_id = ANY($1::uuid[])
+        ORDER BY c.movie_id, c.billing_order, c.id
+    `
+	rows, err := r.pool.Query(ctx, query, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := make(map[string][]domain.Credit)
+	for rows.Next() {
+		var credit domain.Credit
+		if err := rows.Scan(&credit.ID, &credit.MovieID, &credit.PersonID, &credit.Role, &credit.Character, &credit.BillingOrder, &credit.PersonName); err != nil {
+			return nil, err
+		}
+		credits[credit.MovieID] = append(credits[credit.MovieID], credit)
+	}
+	return credits, rows.Err()
+}
+
+// CreditsByPerson lists a person's credits on live movies, newest release first.
+func (r *PeopleRepository) CreditsByPerson(ctx context.Context, personID string) ([]domain.Credit, error) {
+	const query = `
+        SELECT c.id, c.movie_id, c.person_id, c.role, c.character, c.billing_order,
+               m.title, m.slug, m.release_date
+        FROM movie_credits c
+        JOIN movies m ON m.id = c.movie_id
+        WHERE c.person_id = $1 AND m.deleted_at IS NULL
+        ORDER BY m.release_date DESC, c.billing_order, c.id
+    `
+	rows, err := r.pool.Query(ctx, query, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := make([]domain.Credit, 0)
+	for rows.Next() {
+		var credit domain.Credit
+		if err := rows.Scan(&credit.ID, &credit.MovieID, &credit.PersonID, &credit.Role, &credit.Character, &credit.BillingOrder,
+			&credit.MovieTitle, &credit.MovieSlug, &credit.MovieReleaseDate); err != nil {
+			return nil, err
+		}
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
+
+// ReplaceCredits swaps the full set of credits on a movie. Credits naming an
+// unknown person fail with ErrUnknownPerson and duplicates with ErrConflict.
+func (r *PeopleRepository) ReplaceCredits(ctx context.Context, movieID string, credits []CreditParams) ([]domain.Credit, error) {
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM movie_credits WHERE movie_id = $1`, movieID); err != nil {
+			return err
+		}
+		for _, credit := range credits {
+			_, err := tx.Exec(ctx, `
+                INSERT INTO movie_credits (movie_id, person_id, role, character, billing_order)
+                VALUES ($1, $2, $3, $4, $5)
+            `, movieID, credit.PersonID, string(credit.Role), credit.Character, credit.BillingOrder)
+			if err != nil {
+				switch {
+				case isForeignKeyViolation(err), isInvalidTextRepresentation(err):
+					return fmt.Errorf("%w: %s", ErrUnknownPerson, credit.PersonID)
+				}
+				return translateConstraintError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.CreditsByMovie(ctx, movieID)
+}
+
+func scanPerson(row pgx.Row) (domain.Person, error) {
+	var person domain.Person
+	err := row.Scan(&person.ID, &person.Name, &person.BirthDate, &person.Biography, &person.CreatedAt, &person.UpdatedAt)
+	if err != nil {
+		return domain.Person{}, err
+	}
+	return person, nil
+}
@@ -69,6 +69,26 @@ func (r *RatingsRepository) Aggregate(ctx context.Context, movieID string) (doma
 	return agg, nil
 }
 
+// AggregateForCollection pools the ratings of every live movie in a
+// collection, so each rating weighs the same regardless of its movie.
+func (r *RatingsRepository) AggregateForCollection(ctx context.Context, collectionID string) (domain.RatingAggregate, error) {
+	const query = `
+        SELECT COALESCE(ROUND(AVG(r.rating)::numeric, 1), 0)::float4 AS average,
+               COUNT(*)::int8 AS count
+        FROM ratings r
+        JOIN collection_movies cm ON cm.movie_id = r.movie_id
+        JOIN movies m ON m.id = r.movie_id
+        WHERE cm.collection_id = $1 AND m.deleted_at IS NULL
+    `
+
+	var agg domain.RatingAggregate
+	err := r.pool.QueryRow(ctx, query, collectionID).Scan(&agg.Average, &agg.Count)
+	if err != nil {
+		return domain.RatingAggregate{}, fmt.Errorf("aggregate collection ratings: %w", err)
+	}
+	return agg, nil
+}
+
 // Get retrieves a rating for a specific rater/movie combination.
 func (r *RatingsRepository) Get(ctx context.Context, movieID, raterID string) (domain.Rating, error) {
 	const query = `
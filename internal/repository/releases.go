@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// releasesColumn aggregates a movie's regional releases into a JSON array in
+// date order. Dates are emitted as UTC midnight timestamps so they decode
+// into time.Time.
+const releasesColumn = `(
+        SELECT COALESCE(json_agg(json_build_object(
+                   'country', rel.country,
+                   'date', rel.release_date::timestamp AT TIME ZONE 'UTC',
+                   'type', rel.type
+               ) ORDER BY rel.release_date, rel.country, rel.type), '[]'::json)
+        FROM movie_releases rel
+        WHERE rel.movie_id = movies.id
+    )`
+
+// replaceReleases swaps the full set of regional releases of a movie.
+func replaceReleases(ctx context.Context, tx pgx.Tx, movieID string, releases []domain.Release) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM movie_releases WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+	for _, release := range releases {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO movie_releases (movie_id, country, release_date, type)
+            VALUES ($1, $2, $3, $4)
+        `, movieID, release.Country, release.Date, string(release.Type))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("duplicate %s release in %s: %w", release.Type, release.Country, translateConstraintError(err))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// addReleases records releases reported by an external source, keeping any
+// existing entry for the same country and type.
+func addReleases(ctx context.Context, tx pgx.Tx, movieID string, releases []domain.Release) error {
+	for _, release := range releases {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO movie_releases (movie_id, country, release_date, type)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (movie_id, country, type) DO NOTHING
+        `, movieID, release.Country, release.Date, string(release.Type))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseCondition restricts movies by release date. With countries the
+// bounds apply to any release in those countries, otherwise to the primary
+// release date.
+func releaseCondition(countries []string, after, before *time.Time, arg func(interface{}) string) string {
+	if len(countries) == 0 {
+		conds := make([]string, 0, 2)
+		if after != nil {
+			conds = append(conds, fmt.Sprintf("release_date >= %s", arg(*after)))
+		}
+		if before != nil {
+			conds = append(conds, fmt.Sprintf("release_date <= %s", arg(*before)))
+		}
+		return strings.Join(conds, " AND ")
+	}
+	conds := []string{"rel.movie_id = movies.id", fmt.Sprintf("rel.country = ANY(%s)", arg(countries))}
+	if after != nil {
+		conds = append(conds, fmt.Sprintf("rel.release_date >= %s", arg(*after)))
+	}
+	if before != nil {
+		conds = append(conds, fmt.Sprintf("rel.release_date <= %s", arg(*before)))
+	}
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM movie_releases rel WHERE %s)", strings.Join(conds, " AND "))
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/store"
@@ -11,25 +14,155 @@ import (
 // ErrNotFound indicates the requested entity does not exist.
 var ErrNotFound = errors.New("repository: not found")
 
+// ErrConflict indicates the write would violate a uniqueness constraint.
+var ErrConflict = errors.New("repository: conflict")
+
+// ErrConstraint indicates the write would violate a check, foreign key or
+// not-null constraint.
+var ErrConstraint = errors.New("repository: constraint violation")
+
+// ErrAmbiguous indicates a lookup matched more than one entity.
+var ErrAmbiguous = errors.New("repository: ambiguous")
+
+// ErrInvalidCursor indicates a pagination cursor issued for a different
+// listing.
+var ErrInvalidCursor = errors.New("repository: cursor does not match the listing")
+
+// ErrVersionMismatch indicates the row changed since the caller last read it.
+var ErrVersionMismatch = errors.New("repository: version mismatch")
+
 // Repository aggregates all domain-specific repositories.
 type Repository struct {
-	Movies  *MoviesRepository
-	Ratings *RatingsRepository
+	Movies      *MoviesRepository
+	Ratings     *RatingsRepository
+	Revisions   *RevisionsRepository
+	Genres      *GenresRepository
+	People      *PeopleRepository
+	Collections *CollectionsRepository
+	Idempotency *IdempotencyRepository
 }
 
 // New constructs a Repository backed by the provided store.
 func New(st *store.Store) *Repository {
-	pool := st.Pool()
-	return &Repository{
-		Movies:  &MoviesRepository{pool: pool},
-		Ratings: &RatingsRepository{pool: pool},
-	}
+	return NewWithPool(st.Pool())
 }
 
 // NewWithPool allows constructing repositories directly from a pgx pool.
 func NewWithPool(pool *pgxpool.Pool) *Repository {
 	return &Repository{
-		Movies:  &MoviesRepository{pool: pool},
-		Ratings: &RatingsRepository{pool: pool},
+		Movies:      &MoviesRepository{pool: pool},
+		Ratings:     &RatingsRepository{pool: pool},
+		Revisions:   &RevisionsRepository{pool: pool},
+		Genres:      &GenresRepository{pool: pool},
+		People:      &PeopleRepository{pool: pool},
+		Collections: &CollectionsRepository{pool: pool},
+		Idempotency: &IdempotencyRepository{pool: pool},
+	}
+}
+
+// ConstraintError describes a write Postgres rejected because of a
+// constraint. It matches ErrConflict for unique violations and ErrConstraint
+// for the others, and unwraps to the underlying *pgconn.PgError.
+type ConstraintError struct {
+	Constraint string
+	Table      string
+	Column     string
+	kind       error
+	cause      *pgconn.PgError
+}
+
+func (e *ConstraintError) Error() string {
+	name := e.Constraint
+	if name == "" {
+		name = e.Table + "." + e.Column
+	}
+	return fmt.Sprintf("%v (%s)", e.kind, name)
+}
+
+func (e *ConstraintError) Unwrap() []error { return []error{e.kind, e.cause} }
+
+// TitleYearConstraint is the unique index allowing one live movie per title
+// (case-insensitively) and release year.
+const TitleYearConstraint = "uq_movies_title_year"
+
+// IsTitleYearConflict reports whether err is a unique violation of
+// TitleYearConstraint, as opposed to other conflicts such as a duplicate
+// alternate title.
+func IsTitleYearConflict(err error) bool {
+	var constraintErr *ConstraintError
+	return errors.As(err, &constraintErr) && constraintErr.kind == ErrConflict && constraintErr.Constraint == TitleYearConstraint
+}
+
+// translateConstraintError turns unique, check, foreign key and not-null
+// violations into a *ConstraintError and returns other errors unchanged,
+// including errors that already carry a *ConstraintError.
+func translateConstraintError(err error) error {
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) {
+		return err
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	var kind error
+	switch pgErr.Code {
+	case "23505":
+		kind = ErrConflict
+	case "23514", "23503", "23502":
+		kind = ErrConstraint
+	default:
+		return err
+	}
+	return &ConstraintError{
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		kind:       kind,
+		cause:      pgErr,
+	}
+}
+
+// isInvalidTextRepresentation reports whether Postgres rejected a parameter
+// because it could not be parsed (e.g. a malformed UUID).
+func isInvalidTextRepresentation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "22P02"
+}
+
+// isUUIDText reports whether s is a UUID in a spelling Postgres accepts: 32
+// hex digits in any case, optionally in braces, with single hyphens allowed
+// after any group of four digits.
+func isUUIDText(s string) bool {
+	if strings.HasPrefix(s, "{") {
+		if !strings.HasSuffix(s, "}") {
+			return false
+		}
+		s = s[1 : len(s)-1]
+	}
+	digits := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			digits++
+		case c == '-' && digits > 0 && digits < 32 && digits%4 == 0 && s[i-1] != '-':
+		default:
+			return false
+		}
 	}
+	return digits == 32
+}
+
+// isUniqueViolation reports whether Postgres rejected a write because of a
+// unique constraint.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether Postgres rejected a write because of
+// a foreign key constraint.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
 }
@@ -2,18 +2,22 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
@@ -129,7 +133,7 @@ func TestMoviesRepository_CreateGetList(t *testing.T) {
 	}
 
 	movieA := mustCreateMovie(t, env, "Movie A")
-	_, err := env.repository.Movies.UpdateMetadata(env.ctx, movieA.ID, nil, nil, nil, boxOffice)
+	_, err := env.repository.Movies.UpdateMetadata(env.ctx, movieA.ID, nil, nil, nil, boxOffice, nil)
 	if err != nil {
 		t.Fatalf("update metadata: %v", err)
 	}
@@ -187,6 +191,1433 @@ func TestMoviesRepository_CreateGetList(t *testing.T) {
 	}
 }
 
+func TestMoviesRepository_UpdateAndDelete(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	movie := mustCreateMovie(t, env, "Typo Movei")
+	mustCreateMovie(t, env, "Taken Title")
+
+	distributor := "Studio"
+	updated, err := env.repository.Movies.Update(env.ctx, movie.ID, MovieUpdateParams{
+		Title:       "Typo Movie",
+		ReleaseDate: time.Date(2021, time.March, 5, 0, 0, 0, 0, time.UTC),
+		Genre:       "Drama",
+		Distributor: &distributor,
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Title != "Typo Movie" || updated.ReleaseYear != 2021 || updated.Distributor == nil {
+		t.Fatalf("unexpected updated movie: %+v", updated)
+	}
+
+	_, err = env.repository.Movies.Update(env.ctx, movie.ID, MovieUpdateParams{
+		Title:       "taken title",
+		ReleaseDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+		Genre:       updated.Genre,
+	})
+	if err != ErrConflict {
+		t.Fatalf("expected ErrConflict on duplicate title, got %v", err)
+	}
+
+	stale := movie.UpdatedAt
+	_, err = env.repository.Movies.Update(env.ctx, movie.ID, MovieUpdateParams{
+		Title:             updated.Title,
+		ReleaseDate:       updated.ReleaseDate,
+		Genre:             updated.Genre,
+		ExpectedUpdatedAt: &stale,
+	})
+	if err != ErrVersionMismatch {
+		t.Fatalf("expected ErrVersionMismatch for stale update, got %v", err)
+	}
+	if err := env.repository.Movies.Delete(env.ctx, movie.ID, &stale); err != ErrVersionMismatch {
+		t.Fatalf("expected ErrVersionMismatch for stale delete, got %v", err)
+	}
+
+	if _, _, err := env.repository.Ratings.Upsert(env.ctx, RatingUpsertParams{MovieID: movie.ID, RaterID: "user1", Value: 4.0}); err != nil {
+		t.Fatalf("upsert rating: %v", err)
+	}
+	if err := env.repository.Movies.Delete(env.ctx, movie.ID, &updated.UpdatedAt); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := env.repository.Movies.Delete(env.ctx, movie.ID, nil); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+	}
+	if _, err := env.repository.Movies.GetByTitle(env.ctx, "Typo Movie"); err != ErrNotFound {
+		t.Fatalf("expected soft-deleted movie to be hidden, got %v", err)
+	}
+	if _, err := env.repository.Ratings.Get(env.ctx, movie.ID, "user1"); err != nil {
+		t.Fatalf("ratings should survive soft delete: %v", err)
+	}
+
+	trash, err := env.repository.Movies.List(env.ctx, MovieListFilters{Deleted: DeletedOnly})
+	if err != nil {
+		t.Fatalf("list trash: %v", err)
+	}
+	if len(trash.Items) != 1 || trash.Items[0].ID != movie.ID || trash.Items[0].DeletedAt == nil {
+		t.Fatalf("unexpected trash listing: %+v", trash.Items)
+	}
+
+	if _, err := env.repository.Movies.Restore(env.ctx, movie.ID); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := env.repository.Movies.GetByTitle(env.ctx, "Typo Movie"); err != nil {
+		t.Fatalf("restored movie not visible: %v", err)
+	}
+
+	if err := env.repository.Movies.Delete(env.ctx, movie.ID, nil); err != nil {
+		t.Fatalf("delete again: %v", err)
+	}
+	purged, err := env.repository.Movies.PurgeDeleted(env.ctx, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("purged = %d, want 1", purged)
+	}
+	if _, err := env.repository.Ratings.Get(env.ctx, movie.ID, "user1"); err != ErrNotFound {
+		t.Fatalf("expected ratings to cascade on purge, got %v", err)
+	}
+}
+
+func TestMoviesRepository_RestoreConflict(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	original := mustCreateMovie(t, env, "Reused Title")
+	if err := env.repository.Movies.Delete(env.ctx, original.ID, nil); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	mustCreateMovie(t, env, "Reused Title")
+
+	if _, err := env.repository.Movies.Restore(env.ctx, original.ID); err != ErrConflict {
+		t.Fatalf("expected ErrConflict when title is taken, got %v", err)
+	}
+}
+
+func TestMoviesRepository_CreateDuplicate(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	mustCreateMovie(t, env, "Heat")
+	_, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "HEAT",
+		ReleaseDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC),
+		Genre:       "Action",
+	})
+	var constraintErr *ConstraintError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &constraintErr) || constraintErr.Constraint != "uq_movies_title_year" {
+		t.Fatalf("expected ErrConflict on uq_movies_title_year, got %v", err)
+	}
+	if !IsTitleYearConflict(err) {
+		t.Fatalf("expected a title/year conflict, got %v", err)
+	}
+}
+
+func TestMoviesRepository_Remakes(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	for _, year := range []int{1984, 2021} {
+		if _, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+			Title:       "Dune",
+			ReleaseDate: time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC),
+			Genre:       "Sci-Fi",
+		}); err != nil {
+			t.Fatalf("create Dune (%d): %v", year, err)
+		}
+	}
+	if _, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "DUNE",
+		ReleaseDate: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Genre:       "Sci-Fi",
+	}); err == nil {
+		t.Fatalf("expected same title and year to be rejected")
+	}
+
+	if _, err := env.repository.Movies.GetByTitle(env.ctx, "Dune"); err != ErrAmbiguous {
+		t.Fatalf("expected ErrAmbiguous, got %v", err)
+	}
+	all, err := env.repository.Movies.FindByTitle(env.ctx, "dune", nil)
+	if err != nil {
+		t.Fatalf("FindByTitle: %v", err)
+	}
+	if len(all) != 2 || all[0].ReleaseYear != 2021 {
+		t.Fatalf("unexpected candidates: %+v", all)
+	}
+	year := 1984
+	older, err := env.repository.Movies.FindByTitle(env.ctx, "Dune", &year)
+	if err != nil {
+		t.Fatalf("FindByTitle with year: %v", err)
+	}
+	if len(older) != 1 || older[0].ReleaseYear != 1984 {
+		t.Fatalf("unexpected match for 1984: %+v", older)
+	}
+}
+
+func TestMoviesRepository_Slugs(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	release := time.Date(2021, time.October, 22, 0, 0, 0, 0, time.UTC)
+	first, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{Title: "Dune", ReleaseDate: release, Genre: "Sci-Fi"})
+	if err != nil {
+		t.Fatalf("create Dune: %v", err)
+	}
+	second, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{Title: "Dune!", ReleaseDate: release, Genre: "Sci-Fi"})
+	if err != nil {
+		t.Fatalf("create Dune!: %v", err)
+	}
+	if first.Slug != "dune-2021" || second.Slug != "dune-2021-2" {
+		t.Fatalf("slugs = %q, %q; want dune-2021, dune-2021-2", first.Slug, second.Slug)
+	}
+
+	renamed, err := env.repository.Movies.Update(env.ctx, second.ID, MovieUpdateParams{
+		Title:       "Dune: Part One",
+		ReleaseDate: release,
+		Genre:       "Sci-Fi",
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if renamed.Slug != "dune-part-one-2021" {
+		t.Fatalf("renamed slug = %q, want dune-part-one-2021", renamed.Slug)
+	}
+
+	got, redirected, err := env.repository.Movies.GetBySlug(env.ctx, "dune-2021-2")
+	if err != nil {
+		t.Fatalf("GetBySlug old slug: %v", err)
+	}
+	if !redirected || got.ID != second.ID {
+		t.Fatalf("expected redirect to %s, got %+v (redirected=%v)", second.ID, got, redirected)
+	}
+
+	third, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{Title: "Dune?", ReleaseDate: release, Genre: "Sci-Fi"})
+	if err != nil {
+		t.Fatalf("create Dune?: %v", err)
+	}
+	if third.Slug != "dune-2021-3" {
+		t.Fatalf("retired slug reused: got %q, want dune-2021-3", third.Slug)
+	}
+
+	if _, _, err := env.repository.Movies.GetBySlug(env.ctx, "missing-2000"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	amelie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{Title: "Le Fabuleux Destin d'Amélie Poulain", ReleaseDate: release, Genre: "Drama"})
+	if err != nil {
+		t.Fatalf("create Amélie: %v", err)
+	}
+	found, err := env.repository.Movies.FindByTitle(env.ctx, slugify(amelie.Title), nil)
+	if err != nil {
+		t.Fatalf("FindByTitle by slug form: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != amelie.ID {
+		t.Fatalf("expected slug-form lookup of a non-ASCII title, got %+v", found)
+	}
+}
+
+func TestMoviesRepository_AlternateTitles(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	zh, cn := "zh", "CN"
+	movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Inception",
+		ReleaseDate: time.Date(2010, time.July, 16, 0, 0, 0, 0, time.UTC),
+		Genre:       "Sci-Fi",
+		AlternateTitles: []domain.AlternateTitle{
+			{Title: "盗梦空间", Language: &zh, Region: &cn, Kind: domain.TitleKindLocalized},
+			{Title: "Origen", Kind: domain.TitleKindLocalized},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(movie.AlternateTitles) != 2 || movie.AlternateTitles[0].Title != "盗梦空间" {
+		t.Fatalf("unexpected alternate titles: %+v", movie.AlternateTitles)
+	}
+
+	found, err := env.repository.Movies.FindByTitle(env.ctx, "origen", nil)
+	if err != nil {
+		t.Fatalf("FindByTitle: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != movie.ID {
+		t.Fatalf("expected lookup by alternate title, got %+v", found)
+	}
+
+	q := "梦空"
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Query: &q})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != movie.ID {
+		t.Fatalf("expected search by alternate title, got %+v", list.Items)
+	}
+
+	updated, err := env.repository.Movies.Update(env.ctx, movie.ID, MovieUpdateParams{
+		Title:       movie.Title,
+		ReleaseDate: movie.ReleaseDate,
+		Genre:       movie.Genre,
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(updated.AlternateTitles) != 0 {
+		t.Fatalf("expected alternate titles to be replaced, got %+v", updated.AlternateTitles)
+	}
+
+	_, err = env.repository.Movies.Update(env.ctx, movie.ID, MovieUpdateParams{
+		Title:       movie.Title,
+		ReleaseDate: movie.ReleaseDate,
+		Genre:       movie.Genre,
+		AlternateTitles: []domain.AlternateTitle{
+			{Title: "Origen", Kind: domain.TitleKindLocalized},
+			{Title: "ORIGEN", Kind: domain.TitleKindWorking},
+		},
+	})
+	var constraintErr *ConstraintError
+	if !errors.Is(err, ErrConflict) || !errors.As(err, &constraintErr) || constraintErr.Constraint != "uq_movie_titles_variant" {
+		t.Fatalf("expected ErrConflict on uq_movie_titles_variant, got %v", err)
+	}
+	if IsTitleYearConflict(err) {
+		t.Fatalf("duplicate alternate title reported as a title/year conflict: %v", err)
+	}
+}
+
+func TestMoviesRepository_Metadata(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	runtime := 169
+	en := "en"
+	movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Interstellar",
+		ReleaseDate: time.Date(2014, time.November, 7, 0, 0, 0, 0, time.UTC),
+		Genre:       "Sci-Fi",
+		Metadata: MovieMetadata{
+			RuntimeMinutes:      &runtime,
+			OriginalLanguage:    &en,
+			SpokenLanguages:     []string{"en", "fr"},
+			ProductionCountries: []string{"US", "GB"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if movie.RuntimeMinutes == nil || *movie.RuntimeMinutes != 169 || len(movie.SpokenLanguages) != 2 {
+		t.Fatalf("unexpected metadata: %+v", movie)
+	}
+	other := mustCreateMovie(t, env, "Amélie")
+
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Languages: []string{"fr"}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != movie.ID {
+		t.Fatalf("expected match on spoken language, got %+v", list.Items)
+	}
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{Countries: []string{"GB", "FR"}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != movie.ID {
+		t.Fatalf("expected match on production country, got %+v", list.Items)
+	}
+
+	updated, err := env.repository.Movies.Update(env.ctx, other.ID, MovieUpdateParams{
+		Title:       other.Title,
+		ReleaseDate: other.ReleaseDate,
+		Genre:       other.Genre,
+		Metadata:    MovieMetadata{ProductionCountries: []string{"FR"}},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(updated.ProductionCountries) != 1 || updated.ProductionCountries[0] != "FR" {
+		t.Fatalf("unexpected countries after update: %v", updated.ProductionCountries)
+	}
+}
+
+func TestMoviesRepository_Certifications(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	pg13 := "PG-13"
+	movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Inception",
+		ReleaseDate: time.Date(2010, time.July, 16, 0, 0, 0, 0, time.UTC),
+		Genre:       "Sci-Fi",
+		MpaRating:   &pg13,
+		Certifications: []domain.Certification{
+			{Country: "GB", System: "BBFC", Rating: "12A"},
+			{Country: "DE", System: "FSK", Rating: "12"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(movie.Certifications) != 2 || movie.Certifications[0].Country != "DE" {
+		t.Fatalf("unexpected certifications: %+v", movie.Certifications)
+	}
+	mustCreateMovie(t, env, "Other")
+
+	cases := []struct {
+		certs []domain.Certification
+		want  int
+	}{
+		{[]domain.Certification{{Country: "GB", Rating: "12A"}}, 1},
+		{[]domain.Certification{{Country: "GB", Rating: "15"}}, 0},
+		{[]domain.Certification{{Country: "US", Rating: "PG-13"}}, 1},
+		{[]domain.Certification{{Country: "GB", Rating: "15"}, {Country: "DE", Rating: "12"}}, 1},
+	}
+	for _, c := range cases {
+		list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Certifications: c.certs})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		if len(list.Items) != c.want {
+			t.Fatalf("certifications %+v: got %d items, want %d", c.certs, len(list.Items), c.want)
+		}
+	}
+
+	bad := "pg13"
+	if _, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title: "Unnormalized", ReleaseDate: movie.ReleaseDate, Genre: "Drama", MpaRating: &bad,
+	}); err == nil {
+		t.Fatalf("expected check violation for non-canonical mpa rating")
+	}
+}
+
+func TestMoviesRepository_Releases(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Inception",
+		ReleaseDate: day(2010, time.July, 16),
+		Genre:       "Sci-Fi",
+		Releases: []domain.Release{
+			{Country: "CN", Date: day(2010, time.September, 1), Type: domain.ReleaseTypeTheatrical},
+			{Country: "GB", Date: day(2010, time.July, 16), Type: domain.ReleaseTypeTheatrical},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(movie.Releases) != 2 || movie.Releases[0].Country != "GB" || !movie.Releases[1].Date.Equal(day(2010, time.September, 1)) {
+		t.Fatalf("unexpected releases: %+v", movie.Releases)
+	}
+	mustCreateMovie(t, env, "Other")
+
+	after := day(2010, time.August, 1)
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{ReleasedIn: []string{"CN"}, ReleasedAfter: &after})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != movie.ID {
+		t.Fatalf("expected the CN release to match, got %+v", list.Items)
+	}
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{ReleasedIn: []string{"GB"}, ReleasedAfter: &after})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("expected no GB release after %v, got %+v", after, list.Items)
+	}
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{ReleasedBefore: &after})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != movie.ID {
+		t.Fatalf("expected primary release date to be used without releasedIn, got %+v", list.Items)
+	}
+
+	enriched, err := env.repository.Movies.UpdateMetadata(env.ctx, movie.ID, nil, nil, nil, nil, []domain.Release{
+		{Country: "US", Date: day(2010, time.July, 16), Type: domain.ReleaseTypeTheatrical},
+		{Country: "CN", Date: day(2010, time.October, 1), Type: domain.ReleaseTypeTheatrical},
+	})
+	if err != nil {
+		t.Fatalf("UpdateMetadata: %v", err)
+	}
+	if len(enriched.Releases) != 3 {
+		t.Fatalf("expected US release to be added, got %+v", enriched.Releases)
+	}
+	for _, release := range enriched.Releases {
+		if release.Country == "CN" && !release.Date.Equal(day(2010, time.September, 1)) {
+			t.Fatalf("existing CN release should be kept, got %v", release.Date)
+		}
+	}
+}
+
+func TestMoviesRepository_Export(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	first := mustCreateMovie(t, env, "Export A")
+	second := mustCreateMovie(t, env, "Export B")
+	mustCreateMovie(t, env, "Other")
+	for _, rater := range []string{"u1", "u2"} {
+		if _, _, err := env.repository.Ratings.Upsert(env.ctx, RatingUpsertParams{MovieID: second.ID, RaterID: rater, Value: 4.5}); err != nil {
+			t.Fatalf("upsert rating: %v", err)
+		}
+	}
+
+	query := "Export"
+	var (
+		ids  []string
+		aggs []domain.RatingAggregate
+	)
+	err := env.repository.Movies.Export(env.ctx, MovieListFilters{Query: &query, Limit: 1}, func(movie domain.Movie, agg domain.RatingAggregate) error {
+		ids = append(ids, movie.ID)
+		aggs = append(aggs, agg)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != first.ID || ids[1] != second.ID {
+		t.Fatalf("expected both matches oldest first ignoring limit, got %v", ids)
+	}
+	if aggs[0].Count != 0 || aggs[1].Count != 2 || aggs[1].Average != 4.5 {
+		t.Fatalf("unexpected aggregates: %+v", aggs)
+	}
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Query: &query})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if listed := list.Ratings[second.ID]; listed != aggs[1] {
+		t.Fatalf("export aggregate %+v differs from the listing's %+v", aggs[1], listed)
+	}
+
+	ids = nil
+	err = env.repository.Movies.Export(env.ctx, MovieListFilters{Query: &query, Sort: MovieSortTitle, Order: SortOrderDesc}, func(movie domain.Movie, _ domain.RatingAggregate) error {
+		ids = append(ids, movie.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Export sorted: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != second.ID || ids[1] != first.ID {
+		t.Fatalf("expected the export to follow sort=title:desc, got %v", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = env.repository.Movies.Export(env.ctx, MovieListFilters{}, func(domain.Movie, domain.RatingAggregate) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("expected callback error to stop the export, got %v after %d calls", err, calls)
+	}
+}
+
+func TestMoviesRepository_Search(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	day := time.Date(2008, time.July, 18, 0, 0, 0, 0, time.UTC)
+	studio := "Warner Bros."
+	knight, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{Title: "The Dark Knight", ReleaseDate: day, Genre: "Action", Distributor: &studio})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	localized, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:           "Batman Begins",
+		ReleaseDate:     day,
+		Genre:           "Action",
+		AlternateTitles: []domain.AlternateTitle{{Title: "Dark Knight Origins", Kind: domain.TitleKindWorking}},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// Created last, so newest first would list it on top.
+	mustCreateMovie(t, env, "Knight and Day")
+
+	query := "dark knight"
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Query: &query, Sort: MovieSortRelevance, Limit: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != knight.ID || list.NextCursor == nil {
+		t.Fatalf("expected the exact title first, got %+v", list.Items)
+	}
+	cursor, err := DecodeCursor(*list.NextCursor)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	next, err := env.repository.Movies.List(env.ctx, MovieListFilters{Query: &query, Sort: MovieSortRelevance, Limit: 1, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("List page 2: %v", err)
+	}
+	if len(next.Items) != 1 || next.Items[0].ID != localized.ID {
+		t.Fatalf("expected the alternate title match second, got %+v", next.Items)
+	}
+
+	if _, err := env.repository.Movies.List(env.ctx, MovieListFilters{Query: &query, Cursor: cursor}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for a relevance cursor on the default sort, got %v", err)
+	}
+
+	warner := "warner"
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{Query: &warner})
+	if err != nil {
+		t.Fatalf("List by distributor: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != knight.ID {
+		t.Fatalf("expected distributor match, got %+v", list.Items)
+	}
+}
+
+func TestMoviesRepository_ListSorts(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	create := func(title string, year int, budget, revenue *int64) domain.Movie {
+		t.Helper()
+		params := MovieCreateParams{
+			Title:       title,
+			ReleaseDate: time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC),
+			Genre:       "Drama",
+			Budget:      budget,
+		}
+		if revenue != nil {
+			params.BoxOffice = &domain.BoxOffice{Revenue: domain.Revenue{Worldwide: *revenue}, Currency: "USD", Source: "test", LastUpdated: time.Now().UTC()}
+		}
+		movie, err := env.repository.Movies.Create(env.ctx, params)
+		if err != nil {
+			t.Fatalf("create %q: %v", title, err)
+		}
+		return movie
+	}
+	amount := func(v int64) *int64 { return &v }
+
+	alpha := create("alpha", 2001, amount(300), amount(900))
+	bravo := create("Bravo", 1999, nil, amount(500))
+	charlie := create("charlie", 2010, amount(100), nil)
+	delta := create("Delta", 2005, amount(300), amount(700))
+
+	// Walks every page of one item and returns the ids in order.
+	walk := func(filters MovieListFilters) []string {
+		t.Helper()
+		filters.Limit = 1
+		var ids []string
+		for page := 0; page < 10; page++ {
+			list, err := env.repository.Movies.List(env.ctx, filters)
+			if err != nil {
+				t.Fatalf("List(%s %s): %v", filters.Sort, filters.Order, err)
+			}
+			for _, movie := range list.Items {
+				ids = append(ids, movie.ID)
+			}
+			if list.NextCursor == nil {
+				return ids
+			}
+			if filters.Cursor, err = DecodeCursor(*list.NextCursor); err != nil {
+				t.Fatalf("decode cursor: %v", err)
+			}
+		}
+		t.Fatalf("pagination did not terminate")
+		return nil
+	}
+	// Budget and revenue ties are broken by id in the sort direction.
+	tied := []string{alpha.ID, delta.ID}
+	sort.Strings(tied)
+
+	cases := []struct {
+		sort  MovieSort
+		order SortOrder
+		want  []string
+	}{
+		{MovieSortCreated, SortOrderDefault, []string{delta.ID, charlie.ID, bravo.ID, alpha.ID}},
+		{MovieSortReleaseDate, SortOrderDefault, []string{charlie.ID, delta.ID, alpha.ID, bravo.ID}},
+		{MovieSortReleaseDate, SortOrderAsc, []string{bravo.ID, alpha.ID, delta.ID, charlie.ID}},
+		{MovieSortTitle, SortOrderDefault, []string{alpha.ID, bravo.ID, charlie.ID, delta.ID}},
+		{MovieSortTitle, SortOrderDesc, []string{delta.ID, charlie.ID, bravo.ID, alpha.ID}},
+		{MovieSortBudget, SortOrderDefault, []string{tied[1], tied[0], charlie.ID, bravo.ID}},
+		{MovieSortBudget, SortOrderAsc, []string{charlie.ID, tied[0], tied[1], bravo.ID}},
+		{MovieSortRevenue, SortOrderDefault, []string{alpha.ID, delta.ID, bravo.ID, charlie.ID}},
+		{MovieSortRevenue, SortOrderAsc, []string{bravo.ID, delta.ID, alpha.ID, charlie.ID}},
+	}
+	for _, tc := range cases {
+		got := walk(MovieListFilters{Sort: tc.sort, Order: tc.order})
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sort %q %q = %v, want %v", tc.sort, tc.order, got, tc.want)
+		}
+	}
+
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Sort: MovieSortBudget, Limit: 1})
+	if err != nil || list.NextCursor == nil {
+		t.Fatalf("List: %v", err)
+	}
+	cursor, err := DecodeCursor(*list.NextCursor)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	year := 2001
+	for _, filters := range []MovieListFilters{
+		{Sort: MovieSortBudget, Order: SortOrderAsc, Cursor: cursor},
+		{Sort: MovieSortBudget, Year: &year, Cursor: cursor},
+		{Sort: MovieSortTitle, Cursor: cursor},
+	} {
+		if _, err := env.repository.Movies.List(env.ctx, filters); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor for %+v, got %v", filters, err)
+		}
+	}
+}
+
+func TestMoviesRepository_ListRanges(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	create := func(title string, year int, budget, revenue int64) domain.Movie {
+		t.Helper()
+		movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+			Title:       title,
+			ReleaseDate: time.Date(year, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Genre:       "Action",
+			Budget:      &budget,
+			BoxOffice:   &domain.BoxOffice{Revenue: domain.Revenue{Worldwide: revenue}, Currency: "USD", Source: "test", LastUpdated: time.Now().UTC()},
+		})
+		if err != nil {
+			t.Fatalf("create %q: %v", title, err)
+		}
+		return movie
+	}
+	knight := create("The Dark Knight", 2008, 185_000_000, 1_006_000_000)
+	create("Batman Begins", 2005, 150_000_000, 373_000_000)
+	create("Avatar", 2009, 237_000_000, 2_923_000_000)
+	create("Rises", 2013, 150_000_000, 1_081_000_000)
+	mustCreateMovie(t, env, "Unknown Budget")
+
+	from, to := 2005, 2012
+	budgetMin, budgetMax := int64(50_000_000), int64(200_000_000)
+	revenueMin := int64(1_000_000_000)
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{
+		YearFrom: &from, YearTo: &to,
+		BudgetMin: &budgetMin, BudgetMax: &budgetMax,
+		RevenueMin: &revenueMin,
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != knight.ID {
+		t.Fatalf("expected only The Dark Knight, got %+v", list.Items)
+	}
+
+	revenueMax := int64(500_000_000)
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{RevenueMax: &revenueMax})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Title != "Batman Begins" {
+		t.Fatalf("expected only Batman Begins below the revenue cap, got %+v", list.Items)
+	}
+}
+
+func TestMoviesRepository_ListByRating(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	popular := mustCreateMovie(t, env, "Popular")
+	single := mustCreateMovie(t, env, "Single Vote")
+	unrated := mustCreateMovie(t, env, "Unrated")
+	rate := func(movieID, rater string, value float32) {
+		t.Helper()
+		if _, _, err := env.repository.Ratings.Upsert(env.ctx, RatingUpsertParams{MovieID: movieID, RaterID: rater, Value: value}); err != nil {
+			t.Fatalf("upsert rating: %v", err)
+		}
+	}
+	rate(popular.ID, "user1", 3.5)
+	rate(popular.ID, "user1", 4.5) // replaces the first rating
+	rate(popular.ID, "user2", 4.0)
+	rate(single.ID, "user1", 5.0)
+
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Sort: MovieSortRating})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 3 || list.Items[0].ID != single.ID || list.Items[1].ID != popular.ID || list.Items[2].ID != unrated.ID {
+		t.Fatalf("unexpected rating order: %+v", list.Items)
+	}
+	if agg := list.Ratings[popular.ID]; agg.Count != 2 || agg.Average < 4.29 || agg.Average > 4.31 {
+		t.Fatalf("unexpected aggregate for popular: %+v", agg)
+	}
+	if agg := list.Ratings[unrated.ID]; agg.Count != 0 || agg.Average != 0 {
+		t.Fatalf("unexpected aggregate for unrated: %+v", agg)
+	}
+
+	minRating, minVotes := 4.0, int64(2)
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{MinRating: &minRating})
+	if err != nil {
+		t.Fatalf("List minRating: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("expected two movies rated 4 or higher, got %+v", list.Items)
+	}
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{MinRating: &minRating, MinVotes: &minVotes})
+	if err != nil {
+		t.Fatalf("List minVotes: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != popular.ID {
+		t.Fatalf("expected only the movie with two votes, got %+v", list.Items)
+	}
+
+	// The stats follow the ratings when a movie is deleted and purged.
+	if err := env.repository.Movies.Delete(env.ctx, single.ID, nil); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := env.repository.Movies.PurgeDeleted(env.ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	var stats int
+	if err := env.pool.QueryRow(env.ctx, `SELECT COUNT(*) FROM movie_rating_stats`).Scan(&stats); err != nil {
+		t.Fatalf("count stats: %v", err)
+	}
+	if stats != 1 {
+		t.Fatalf("stats rows = %d, want 1", stats)
+	}
+}
+
+func TestMoviesRepository_Facets(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	warner, pg13 := "Warner Bros.", "PG-13"
+	create := func(title string, year int, genres []string, distributor, mpa *string) {
+		t.Helper()
+		if _, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+			Title:       title,
+			ReleaseDate: time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC),
+			Genres:      genres,
+			Distributor: distributor,
+			MpaRating:   mpa,
+		}); err != nil {
+			t.Fatalf("create %q: %v", title, err)
+		}
+	}
+	create("Inception", 2010, []string{"Science Fiction", "Action"}, &warner, &pg13)
+	create("Dunkirk", 2017, []string{"Drama"}, &warner, &pg13)
+	warnerLower := "warner bros."
+	create("Tenet", 2020, []string{"Action"}, &warnerLower, nil)
+	create("Amelie", 2001, []string{"Drama"}, nil, nil)
+
+	yearFrom := 2010
+	filters := MovieListFilters{YearFrom: &yearFrom, Limit: 1}
+	counts, err := env.repository.Movies.Facets(env.ctx, filters, []MovieFacet{MovieFacetGenre, MovieFacetYear, MovieFacetDistributor, MovieFacetMpaRating})
+	if err != nil {
+		t.Fatalf("Facets: %v", err)
+	}
+	if counts.Total != 3 {
+		t.Fatalf("total = %d, want 3 regardless of the page size", counts.Total)
+	}
+	if genres := counts.Facets[MovieFacetGenre]; len(genres) != 3 || genres[0] != (FacetBucket{Value: "Action", Count: 2}) {
+		t.Fatalf("unexpected genre buckets: %+v", genres)
+	}
+	if years := counts.Facets[MovieFacetYear]; len(years) != 3 || years[0] != (FacetBucket{Value: "2010", Count: 1}) {
+		t.Fatalf("unexpected year buckets: %+v", years)
+	}
+	if distributors := counts.Facets[MovieFacetDistributor]; len(distributors) != 1 || distributors[0] != (FacetBucket{Value: warner, Count: 3}) {
+		t.Fatalf("unexpected distributor buckets: %+v", distributors)
+	}
+	if ratings := counts.Facets[MovieFacetMpaRating]; len(ratings) != 1 || ratings[0] != (FacetBucket{Value: "PG-13", Count: 2}) {
+		t.Fatalf("unexpected mpaRating buckets: %+v", ratings)
+	}
+
+	counts, err = env.repository.Movies.Facets(env.ctx, MovieListFilters{}, nil)
+	if err != nil {
+		t.Fatalf("Facets total only: %v", err)
+	}
+	if counts.Total != 4 || len(counts.Facets) != 0 {
+		t.Fatalf("unexpected total-only counts: %+v", counts)
+	}
+
+	for year := 1980; year < 1980+FacetBucketLimit; year++ {
+		create(fmt.Sprintf("Filler %d", year), year, []string{"Drama"}, nil, nil)
+	}
+	counts, err = env.repository.Movies.Facets(env.ctx, MovieListFilters{}, []MovieFacet{MovieFacetYear})
+	if err != nil {
+		t.Fatalf("Facets after fillers: %v", err)
+	}
+	if years := counts.Facets[MovieFacetYear]; len(years) != FacetBucketLimit || counts.Total != 4+FacetBucketLimit {
+		t.Fatalf("expected %d year buckets over %d movies, got %d over %d", FacetBucketLimit, 4+FacetBucketLimit, len(years), counts.Total)
+	}
+}
+
+func TestMoviesRepository_ListFields(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	distributor, poster := "Warner Bros.", "https://example.com/inception.jpg"
+	movie, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Inception",
+		ReleaseDate: time.Date(2010, time.July, 16, 0, 0, 0, 0, time.UTC),
+		Genre:       "Science Fiction",
+		Genres:      []string{"Action"},
+		Distributor: &distributor,
+		BoxOffice:   &domain.BoxOffice{Revenue: domain.Revenue{Worldwide: 829_895_144}, Currency: "USD", Source: "test", LastUpdated: time.Now().UTC()},
+		Metadata:    MovieMetadata{PosterURL: &poster},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Fields: []MovieField{MovieFieldPosterURL, MovieFieldGenres}})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("expected one movie, got %d", len(list.Items))
+	}
+	got := list.Items[0]
+	if got.ID != movie.ID || got.Title != "Inception" || got.Slug != movie.Slug || got.PosterURL == nil || *got.PosterURL != poster {
+		t.Fatalf("selected fields not loaded: %+v", got)
+	}
+	if len(got.Genres) != 2 || got.Genres[1] != "Action" {
+		t.Fatalf("genres not loaded: %v", got.Genres)
+	}
+	if got.Distributor != nil || got.BoxOffice != nil {
+		t.Fatalf("unselected fields were loaded: %+v", got)
+	}
+
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{Fields: []MovieField{}})
+	if err != nil {
+		t.Fatalf("List core fields: %v", err)
+	}
+	if got := list.Items[0]; got.ID != movie.ID || got.PosterURL != nil || len(got.Genres) != 0 {
+		t.Fatalf("expected only core fields, got %+v", got)
+	}
+}
+
+func TestMoviesRepository_Suggest(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	inception := mustCreateMovie(t, env, "Inception")
+	interstellar := mustCreateMovie(t, env, "Interstellar")
+	mustCreateMovie(t, env, "The Matrix")
+	deleted := mustCreateMovie(t, env, "Insomnia")
+	if err := env.repository.Movies.Delete(env.ctx, deleted.ID, nil); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	suggestions, err := env.repository.Movies.Suggest(env.ctx, "Incepshun", 5)
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if len(suggestions) == 0 || suggestions[0].ID != inception.ID || suggestions[0].PrefixMatch {
+		t.Fatalf("expected a fuzzy match on Inception, got %+v", suggestions)
+	}
+
+	suggestions, err = env.repository.Movies.Suggest(env.ctx, "in", 5)
+	if err != nil {
+		t.Fatalf("Suggest prefix: %v", err)
+	}
+	if len(suggestions) != 2 || !suggestions[0].PrefixMatch || !suggestions[1].PrefixMatch {
+		t.Fatalf("expected the two live titles starting with \"in\", got %+v", suggestions)
+	}
+	if suggestions[0].ID != inception.ID || suggestions[1].ID != interstellar.ID || suggestions[0].Slug != inception.Slug {
+		t.Fatalf("unexpected prefix order: %+v", suggestions)
+	}
+}
+
+func TestMoviesRepository_Genres(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	release := time.Date(2014, time.November, 7, 0, 0, 0, 0, time.UTC)
+	interstellar, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Interstellar",
+		ReleaseDate: release,
+		Genre:       "sci fi",
+		Genres:      []string{"Drama", "Science-Fiction"},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if interstellar.Genre != "sci fi" || len(interstellar.Genres) != 2 || interstellar.Genres[0] != "Science Fiction" || interstellar.Genres[1] != "Drama" {
+		t.Fatalf("unexpected genres: %q %v", interstellar.Genre, interstellar.Genres)
+	}
+	mustCreateMovie(t, env, "Plain Action")
+
+	kaiju, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Unknown",
+		ReleaseDate: release,
+		Genre:       "Kaiju",
+	})
+	if err != nil {
+		t.Fatalf("create with free-text genre: %v", err)
+	}
+	if kaiju.Genre != "Kaiju" || len(kaiju.Genres) != 1 || kaiju.Genres[0] != "Kaiju" {
+		t.Fatalf("unexpected free-text genres: %q %v", kaiju.Genre, kaiju.Genres)
+	}
+	if _, err := env.repository.Movies.Create(env.ctx, MovieCreateParams{
+		Title:       "Symbols",
+		ReleaseDate: release,
+		Genre:       "!!",
+	}); !errors.Is(err, ErrUnknownGenre) {
+		t.Fatalf("expected ErrUnknownGenre, got %v", err)
+	}
+
+	anyMatch, err := env.repository.Movies.List(env.ctx, MovieListFilters{Genres: []string{"SF", "action"}})
+	if err != nil {
+		t.Fatalf("list any: %v", err)
+	}
+	if len(anyMatch.Items) != 2 {
+		t.Fatalf("any match returned %d movies, want 2", len(anyMatch.Items))
+	}
+	all, err := env.repository.Movies.List(env.ctx, MovieListFilters{Genres: []string{"scifi", "drama"}, GenreMatch: GenreMatchAll})
+	if err != nil {
+		t.Fatalf("list all: %v", err)
+	}
+	if len(all.Items) != 1 || all.Items[0].ID != interstellar.ID {
+		t.Fatalf("all match returned %+v", all.Items)
+	}
+
+	if _, err := env.repository.Genres.Create(env.ctx, "Disaster", []string{"Giant Monster"}); err != nil {
+		t.Fatalf("create genre: %v", err)
+	}
+	if _, err := env.repository.Genres.Create(env.ctx, "Monster", []string{"giant-monster"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict for taken synonym, got %v", err)
+	}
+}
+
+func TestPeopleRepository_Credits(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	nolan, err := env.repository.People.Create(env.ctx, PersonParams{Name: "Christopher Nolan"})
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	dicaprio, err := env.repository.People.Create(env.ctx, PersonParams{Name: "Leonardo DiCaprio"})
+	if err != nil {
+		t.Fatalf("create person: %v", err)
+	}
+	inception := mustCreateMovie(t, env, "Inception")
+	mustCreateMovie(t, env, "Other")
+
+	cobb := "Cobb"
+	credits, err := env.repository.People.ReplaceCredits(env.ctx, inception.ID, []CreditParams{
+		{PersonID: dicaprio.ID, Role: domain.CreditRoleActor, Character: &cobb, BillingOrder: 1},
+		{PersonID: nolan.ID, Role: domain.CreditRoleDirector, BillingOrder: 0},
+		{PersonID: nolan.ID, Role: domain.CreditRoleWriter, BillingOrder: 2},
+	})
+	if err != nil {
+		t.Fatalf("replace credits: %v", err)
+	}
+	if len(credits) != 3 || credits[0].PersonName != "Christopher Nolan" || credits[1].Character == nil {
+		t.Fatalf("unexpected credits: %+v", credits)
+	}
+
+	if _, err := env.repository.People.ReplaceCredits(env.ctx, inception.ID, []CreditParams{
+		{PersonID: "00000000-0000-0000-0000-000000000000", Role: domain.CreditRoleActor},
+	}); !errors.Is(err, ErrUnknownPerson) {
+		t.Fatalf("expected ErrUnknownPerson, got %v", err)
+	}
+
+	filmography, err := env.repository.People.CreditsByPerson(env.ctx, nolan.ID)
+	if err != nil {
+		t.Fatalf("credits by person: %v", err)
+	}
+	if len(filmography) != 2 || filmography[0].MovieTitle != "Inception" {
+		t.Fatalf("unexpected filmography: %+v", filmography)
+	}
+
+	name := "christopher nolan"
+	director := domain.CreditRoleDirector
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Person: &name, PersonRole: &director})
+	if err != nil {
+		t.Fatalf("list by person: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != inception.ID {
+		t.Fatalf("unexpected movies for person: %+v", list.Items)
+	}
+	actor := domain.CreditRoleActor
+	list, err = env.repository.Movies.List(env.ctx, MovieListFilters{Person: &nolan.ID, PersonRole: &actor})
+	if err != nil {
+		t.Fatalf("list by person id: %v", err)
+	}
+	if len(list.Items) != 0 {
+		t.Fatalf("expected no acting credits, got %+v", list.Items)
+	}
+
+	var constraintErr *ConstraintError
+	if err := env.repository.People.Delete(env.ctx, nolan.ID); !errors.Is(err, ErrConstraint) || !errors.As(err, &constraintErr) || constraintErr.Constraint != "movie_credits_person_id_fkey" {
+		t.Fatalf("expected ErrConstraint on movie_credits_person_id_fkey deleting credited person, got %v", err)
+	}
+}
+
+func TestCollectionsRepository_Membership(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	batman := mustCreateMovie(t, env, "Batman Begins")
+	knight := mustCreateMovie(t, env, "The Dark Knight")
+	rises := mustCreateMovie(t, env, "The Dark Knight Rises")
+	mustCreateMovie(t, env, "Memento")
+
+	trilogy, err := env.repository.Collections.Create(env.ctx, CollectionParams{Name: "The Dark Knight Trilogy"})
+	if err != nil {
+		t.Fatalf("create collection: %v", err)
+	}
+	var constraintErr *ConstraintError
+	if _, err := env.repository.Collections.Create(env.ctx, CollectionParams{Name: "the dark knight trilogy"}); !errors.Is(err, ErrConflict) || !errors.As(err, &constraintErr) || constraintErr.Constraint != "uq_collections_name" {
+		t.Fatalf("expected ErrConflict on uq_collections_name for duplicate name, got %v", err)
+	}
+
+	if err := env.repository.Collections.ReplaceMovies(env.ctx, trilogy.ID, []string{batman.ID, knight.ID, rises.ID}); err != nil {
+		t.Fatalf("replace movies: %v", err)
+	}
+	// UUIDs compare by value, not by spelling.
+	if err := env.repository.Collections.ReplaceMovies(env.ctx, trilogy.ID, []string{strings.ToUpper(batman.ID), "{" + knight.ID + "}", rises.ID}); err != nil {
+		t.Fatalf("replace with other UUID spellings: %v", err)
+	}
+	if err := env.repository.Collections.ReplaceMovies(env.ctx, trilogy.ID, []string{batman.ID, strings.ToUpper(batman.ID)}); !errors.Is(err, ErrInvalidMovieIDs) {
+		t.Fatalf("expected ErrInvalidMovieIDs for a repeated movie, got %v", err)
+	}
+	if err := env.repository.Collections.ReplaceMovies(env.ctx, trilogy.ID, []string{"not-a-uuid"}); !errors.Is(err, ErrInvalidMovieIDs) {
+		t.Fatalf("expected ErrInvalidMovieIDs for a malformed id, got %v", err)
+	}
+	if err := env.repository.Collections.ReplaceMovies(env.ctx, trilogy.ID, []string{"00000000-0000-0000-0000-000000000000"}); !errors.Is(err, ErrUnknownMovie) {
+		t.Fatalf("expected ErrUnknownMovie, got %v", err)
+	}
+
+	movies, err := env.repository.Collections.Movies(env.ctx, trilogy.ID)
+	if err != nil {
+		t.Fatalf("collection movies: %v", err)
+	}
+	if len(movies) != 3 || movies[0].ID != batman.ID || movies[2].ID != rises.ID {
+		t.Fatalf("unexpected order: %+v", movies)
+	}
+
+	name := "The Dark Knight Trilogy"
+	list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Collection: &name})
+	if err != nil {
+		t.Fatalf("list by collection: %v", err)
+	}
+	if len(list.Items) != 3 {
+		t.Fatalf("collection filter returned %d movies, want 3", len(list.Items))
+	}
+	for _, value := range []string{strings.ToUpper(trilogy.ID), "{" + trilogy.ID + "}", "not-a-uuid", "00000000-0000-0000-0000-000000000000"} {
+		want := 0
+		if strings.Contains(value, trilogy.ID) || strings.EqualFold(value, trilogy.ID) {
+			want = 3
+		}
+		list, err := env.repository.Movies.List(env.ctx, MovieListFilters{Collection: &value})
+		if err != nil {
+			t.Fatalf("list by collection %q: %v", value, err)
+		}
+		if len(list.Items) != want {
+			t.Fatalf("collection filter %q returned %d movies, want %d", value, len(list.Items), want)
+		}
+	}
+
+	for _, rating := range []RatingUpsertParams{
+		{MovieID: batman.ID, RaterID: "u1", Value: 4.0},
+		{MovieID: knight.ID, RaterID: "u1", Value: 5.0},
+		{MovieID: knight.ID, RaterID: "u2", Value: 4.5},
+	} {
+		if _, _, err := env.repository.Ratings.Upsert(env.ctx, rating); err != nil {
+			t.Fatalf("upsert rating: %v", err)
+		}
+	}
+	agg, err := env.repository.Ratings.AggregateForCollection(env.ctx, trilogy.ID)
+	if err != nil {
+		t.Fatalf("aggregate: %v", err)
+	}
+	if agg.Count != 3 || agg.Average != 4.5 {
+		t.Fatalf("aggregate = %+v, want {4.5 3}", agg)
+	}
+}
+
+func TestIdempotencyRepository_Lifecycle(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	repo := env.repository.Idempotency
+	expired := time.Now().Add(-24 * time.Hour)
+	stale := time.Now().Add(-time.Minute)
+	first, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, stale)
+	if err != nil || !claimed {
+		t.Fatalf("first Begin: claimed=%v err=%v", claimed, err)
+	}
+
+	record, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, stale)
+	if err != nil || claimed {
+		t.Fatalf("second Begin: claimed=%v err=%v", claimed, err)
+	}
+	if record.StatusCode != 0 {
+		t.Fatalf("expected in-flight record, got status %d", record.StatusCode)
+	}
+
+	if err := repo.Complete(env.ctx, "POST /movies", "k1", first.ClaimedAt, 201, map[string]string{"Location": "/movies/heat-1995"}, []byte(`{"id":"m1"}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	record, claimed, err = repo.Begin(env.ctx, "POST /movies", "k1", "hash-b", expired, stale)
+	if err != nil || claimed {
+		t.Fatalf("Begin after Complete: claimed=%v err=%v", claimed, err)
+	}
+	if record.RequestHash != "hash-a" || record.StatusCode != 201 || record.Headers["Location"] != "/movies/heat-1995" || string(record.Body) != `{"id":"m1"}` {
+		t.Fatalf("unexpected stored record: %+v", record)
+	}
+
+	if _, claimed, err := repo.Begin(env.ctx, "POST /ratings u1", "k1", "hash-b", expired, stale); err != nil || !claimed {
+		t.Fatalf("keys should be scoped: claimed=%v err=%v", claimed, err)
+	}
+	record, claimed, err = repo.Begin(env.ctx, "POST /movies", "k1", "hash-b", time.Now().Add(time.Minute), stale)
+	if err != nil || !claimed {
+		t.Fatalf("expired key should be taken over: claimed=%v err=%v", claimed, err)
+	}
+
+	if err := repo.Release(env.ctx, "POST /movies", "k1", record.ClaimedAt); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-c", expired, stale); err != nil || !claimed {
+		t.Fatalf("released key should be claimable: claimed=%v err=%v", claimed, err)
+	}
+
+	removed, err := repo.PurgeExpired(env.ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeExpired: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("purged %d keys, want 2", removed)
+	}
+}
+
+func TestIdempotencyRepository_ReclaimsStaleClaim(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	repo := env.repository.Idempotency
+	expired := time.Now().Add(-24 * time.Hour)
+	stale := time.Now().Add(-time.Minute)
+	crashed, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, stale)
+	if err != nil || !claimed {
+		t.Fatalf("first Begin: claimed=%v err=%v", claimed, err)
+	}
+	if _, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, stale); err != nil || claimed {
+		t.Fatalf("fresh claim must stay in progress: claimed=%v err=%v", claimed, err)
+	}
+
+	// Once the lease has run out, only a retry of the same request takes over.
+	leaseOver := time.Now().Add(time.Minute)
+	record, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-b", expired, leaseOver)
+	if err != nil || claimed || record.RequestHash != "hash-a" {
+		t.Fatalf("stale claim taken over by another request: claimed=%v record=%+v err=%v", claimed, record, err)
+	}
+	retry, claimed, err := repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, leaseOver)
+	if err != nil || !claimed {
+		t.Fatalf("stale claim should be reclaimed: claimed=%v err=%v", claimed, err)
+	}
+	if !retry.ClaimedAt.After(crashed.ClaimedAt) {
+		t.Fatalf("reclaim must renew the claim: %v -> %v", crashed.ClaimedAt, retry.ClaimedAt)
+	}
+
+	// The original request, if it was only slow, can no longer touch the key.
+	if err := repo.Complete(env.ctx, "POST /movies", "k1", crashed.ClaimedAt, 201, nil, []byte(`{}`)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Complete with a lost claim: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Release(env.ctx, "POST /movies", "k1", crashed.ClaimedAt); err != nil {
+		t.Fatalf("Release with a lost claim: %v", err)
+	}
+	if err := repo.Complete(env.ctx, "POST /movies", "k1", retry.ClaimedAt, 201, nil, []byte(`{"id":"m1"}`)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	record, claimed, err = repo.Begin(env.ctx, "POST /movies", "k1", "hash-a", expired, leaseOver)
+	if err != nil || claimed || record.StatusCode != 201 || string(record.Body) != `{"id":"m1"}` {
+		t.Fatalf("completed key must be replayed, not reclaimed: claimed=%v record=%+v err=%v", claimed, record, err)
+	}
+}
+
+func TestTranslateConstraintError(t *testing.T) {
+	unique := translateConstraintError(&pgconn.PgError{Code: "23505", ConstraintName: "uq_movies_title_year"})
+	var constraintErr *ConstraintError
+	if !errors.Is(unique, ErrConflict) || errors.Is(unique, ErrConstraint) || !errors.As(unique, &constraintErr) || constraintErr.Constraint != "uq_movies_title_year" {
+		t.Fatalf("unique violation translated to %v", unique)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(unique, &pgErr) {
+		t.Fatalf("expected the pg error to stay reachable")
+	}
+
+	for _, code := range []string{"23514", "23503", "23502"} {
+		err := translateConstraintError(fmt.Errorf("insert: %w", &pgconn.PgError{Code: code, TableName: "movies", ColumnName: "budget"}))
+		if !errors.Is(err, ErrConstraint) || errors.Is(err, ErrConflict) {
+			t.Fatalf("code %s translated to %v", code, err)
+		}
+		if err.Error() != "repository: constraint violation (movies.budget)" {
+			t.Fatalf("code %s message = %q", code, err.Error())
+		}
+	}
+
+	if !IsTitleYearConflict(fmt.Errorf("update: %w", unique)) {
+		t.Fatalf("expected %v to be a title/year conflict", unique)
+	}
+	titleErr := fmt.Errorf("duplicate alternate title %q: %w", "Origen", translateConstraintError(&pgconn.PgError{Code: "23505", ConstraintName: "uq_movie_titles_variant"}))
+	if got := translateConstraintError(titleErr); got != titleErr || IsTitleYearConflict(got) || !errors.Is(got, ErrConflict) {
+		t.Fatalf("translated constraint errors must pass through unchanged, got %v", got)
+	}
+
+	other := &pgconn.PgError{Code: "22P02"}
+	if err := translateConstraintError(other); err != other {
+		t.Fatalf("unrelated error should pass through, got %v", err)
+	}
+	if err := translateConstraintError(ErrNotFound); err != ErrNotFound {
+		t.Fatalf("non-pg error should pass through, got %v", err)
+	}
+}
+
+func TestIsUUIDText(t *testing.T) {
+	cases := map[string]bool{
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11":    true,
+		"A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11":    true,
+		"{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11}":  true,
+		"a0eebc999c0b4ef8bb6d6bb9bd380a11":        true,
+		"a0ee-bc99-9c0b-4ef8-bb6d-6bb9-bd38-0a11": true,
+		"":                                      false,
+		"The Dark Knight Trilogy":               false,
+		"{a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11": false,
+		"-a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11": false,
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11-": false,
+		"a0eebc99--9c0b-4ef8-bb6d-6bb9bd380a11": false,
+		"a0eebc9-99c0b-4ef8-bb6d-6bb9bd380a11":  false,
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a1":   false,
+		"g0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11":  false,
+	}
+	for input, want := range cases {
+		if got := isUUIDText(input); got != want {
+			t.Fatalf("isUUIDText(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
+
+func TestGenreKey(t *testing.T) {
+	for _, input := range []string{"Sci-Fi", "sci fi", "SciFi", " SCI_FI "} {
+		if got := GenreKey(input); got != "scifi" {
+			t.Fatalf("GenreKey(%q) = %q, want scifi", input, got)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"The Dark Knight":         "the-dark-knight",
+		"  Spider-Man: No Way!  ": "spider-man-no-way",
+		"Amélie":                  "amélie",
+		"盗梦空间":                    "盗梦空间",
+		"!!!":                     "",
+	}
+	for input, want := range cases {
+		if got := slugify(input); got != want {
+			t.Fatalf("slugify(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if got := baseSlug("!!!", 1999); got != "movie-1999" {
+		t.Fatalf("baseSlug fallback = %q, want movie-1999", got)
+	}
+}
+
+func TestRevisionsRepository_RecordsChanges(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.cleanup()
+
+	ctx := WithActor(env.ctx, "editor-1")
+	movie, err := env.repository.Movies.Create(ctx, MovieCreateParams{
+		Title:       "Audited",
+		ReleaseDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Genre:       "Action",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	distributor := "Upstream Studio"
+	if _, err := env.repository.Movies.UpdateMetadata(env.ctx, movie.ID, &distributor, nil, nil, nil, nil); err != nil {
+		t.Fatalf("update metadata: %v", err)
+	}
+
+	first, err := env.repository.Revisions.ListByMovie(env.ctx, movie.ID, RevisionListFilters{Limit: 1})
+	if err != nil {
+		t.Fatalf("list revisions: %v", err)
+	}
+	if len(first.Items) != 1 || first.NextCursor == nil {
+		t.Fatalf("expected one revision and a cursor, got %+v", first)
+	}
+	latest := first.Items[0]
+	if latest.Source != domain.RevisionSourceBoxOffice || len(latest.ChangedFields) != 1 || latest.ChangedFields[0] != "distributor" {
+		t.Fatalf("unexpected latest revision: %+v", latest)
+	}
+
+	cursor, err := DecodeRevisionCursor(*first.NextCursor)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	second, err := env.repository.Revisions.ListByMovie(env.ctx, movie.ID, RevisionListFilters{Limit: 1, Cursor: cursor})
+	if err != nil {
+		t.Fatalf("list revisions page 2: %v", err)
+	}
+	if len(second.Items) != 1 {
+		t.Fatalf("second page size = %d, want 1", len(second.Items))
+	}
+	created := second.Items[0]
+	if created.Actor != "editor-1" || created.Source != domain.RevisionSourceUser || created.Before != nil {
+		t.Fatalf("unexpected creation revision: %+v", created)
+	}
+
+	if _, err := env.pool.Exec(env.ctx, `DELETE FROM movie_revisions WHERE id = $1`, created.ID); err == nil {
+		t.Fatalf("expected movie_revisions to reject deletes")
+	}
+
+	if _, err := env.repository.Movies.Update(ctx, movie.ID, MovieUpdateParams{
+		Title:       movie.Title,
+		ReleaseDate: movie.ReleaseDate,
+		Genre:       "Action",
+		Genres:      []string{"Drama"},
+		Distributor: &distributor,
+	}); err != nil {
+		t.Fatalf("update genres: %v", err)
+	}
+	latestPage, err := env.repository.Revisions.ListByMovie(env.ctx, movie.ID, RevisionListFilters{Limit: 1})
+	if err != nil {
+		t.Fatalf("list revisions after genre change: %v", err)
+	}
+	if len(latestPage.Items) != 1 || len(latestPage.Items[0].ChangedFields) != 1 || latestPage.Items[0].ChangedFields[0] != "genres" {
+		t.Fatalf("expected a genres-only revision, got %+v", latestPage.Items)
+	}
+}
+
+func TestDiffMovies(t *testing.T) {
+	budget := int64(10)
+	before := domain.Movie{Title: "A", Genre: "Action", ReleaseDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	after := before
+	after.Budget = &budget
+
+	fields, prev, next, err := diffMovies(&before, &after)
+	if err != nil {
+		t.Fatalf("diffMovies: %v", err)
+	}
+	if len(fields) != 1 || fields[0] != "budget" {
+		t.Fatalf("fields = %v, want [budget]", fields)
+	}
+	if string(prev["budget"]) != "null" || string(next["budget"]) != "10" {
+		t.Fatalf("unexpected diff: before=%s after=%s", prev["budget"], next["budget"])
+	}
+
+	if fields, _, _, _ := diffMovies(&before, &before); len(fields) != 0 {
+		t.Fatalf("expected no changes, got %v", fields)
+	}
+
+	before.Genres = []string{"Action"}
+	after = before
+	after.Genres = []string{"Action", "Drama"}
+	if fields, _, _, _ := diffMovies(&before, &after); len(fields) != 1 || fields[0] != "genres" {
+		t.Fatalf("fields = %v, want [genres]", fields)
+	}
+}
+
 func TestRatingsRepository_UpsertAndAggregate(t *testing.T) {
 	env := newTestEnv(t)
 	defer env.cleanup()
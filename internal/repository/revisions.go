@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// defaultActor is recorded when a write happens without an actor in context.
+const defaultActor = "system"
+
+// RevisionsRepository reads the movie audit trail. Revisions are written by
+// MoviesRepository inside the same transaction as the change they describe.
+type RevisionsRepository struct {
+	pool *pgxpool.Pool
+}
+
+// RevisionListFilters encapsulates pagination options for a movie's history.
+type RevisionListFilters struct {
+	Limit  int
+	Cursor *RevisionCursor
+}
+
+// RevisionCursor allows stable pagination by revision id.
+type RevisionCursor struct {
+	ID int64 `json:"id"`
+}
+
+// RevisionListResult returns the paginated payload.
+type RevisionListResult struct {
+	Items      []domain.MovieRevision
+	NextCursor *string
+}
+
+type actorContextKey struct{}
+
+// WithActor attaches the identity recorded on revisions written with ctx.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+func actorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return defaultActor
+}
+
+// ListByMovie returns a movie's revisions, newest first.
+func (r *RevisionsRepository) ListByMovie(ctx context.Context, movieID string, filters RevisionListFilters) (RevisionListResult, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 20
+	} else if filters.Limit > 100 {
+		filters.Limit = 100
+	}
+
+	args := []interface{}{movieID}
+	query := `
+        SELECT id, movie_id, actor, source, changed_fields, before_state, after_state, created_at
+        FROM movie_revisions
+        WHERE movie_id = $1`
+	if filters.Cursor != nil {
+		args = append(args, filters.Cursor.ID)
+		query += ` AND id < $2`
+	}
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT %d`, filters.Limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return RevisionListResult{}, err
+	}
+	defer rows.Close()
+
+	items := make([]domain.MovieRevision, 0)
+	for rows.Next() {
+		var (
+			rev    domain.MovieRevision
+			source string
+		)
+		if err := rows.Scan(&rev.ID, &rev.MovieID, &rev.Actor, &source, &rev.ChangedFields, &rev.Before, &rev.After, &rev.CreatedAt); err != nil {
+			return RevisionListResult{}, err
+		}
+		rev.Source = domain.RevisionSource(source)
+		items = append(items, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return RevisionListResult{}, err
+	}
+
+	var nextCursor *string
+	if len(items) == filters.Limit {
+		token, err := encodeToken(RevisionCursor{ID: items[len(items)-1].ID})
+		if err != nil {
+			return RevisionListResult{}, err
+		}
+		nextCursor = &token
+	}
+	return RevisionListResult{Items: items, NextCursor: nextCursor}, nil
+}
+
+// DecodeRevisionCursor parses a cursor token into a RevisionCursor.
+func DecodeRevisionCursor(token string) (*RevisionCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var cursor RevisionCursor
+	if err := decodeToken(token, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// insertRevision records the field-level difference between before and after.
+// A nil before marks creation. Nothing is written when no field changed.
+func insertRevision(ctx context.Context, tx pgx.Tx, source domain.RevisionSource, before, after *domain.Movie) error {
+	fields, beforeDiff, afterDiff, err := diffMovies(before, after)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	var beforeJSON []byte
+	if before != nil {
+		if beforeJSON, err = json.Marshal(beforeDiff); err != nil {
+			return err
+		}
+	}
+	afterJSON, err := json.Marshal(afterDiff)
+	if err != nil {
+		return err
+	}
+
+	movieID := after.ID
+	const query = `
+        INSERT INTO movie_revisions (movie_id, actor, source, changed_fields, before_state, after_state)
+        VALUES ($1,$2,$3,$4,$5,$6)
+    `
+	_, err = tx.Exec(ctx, query, movieID, actorFromContext(ctx), string(source), fields, beforeJSON, afterJSON)
+	return err
+}
+
+// movieRevisionFields lists the audited fields in a stable order, keyed by
+// their API names.
+var movieRevisionFields = []string{
+	"title", "slug", "releaseDate", "genre", "genres", "distributor", "budget", "mpaRating", "runtimeMinutes", "originalLanguage", "spokenLanguages",
+	"productionCountries", "synopsis", "posterUrl", "certifications", "releases", "boxOffice", "alternateTitles", "deletedAt",
+}
+
+func movieSnapshot(movie *domain.Movie) map[string]interface{} {
+	if movie == nil {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"title":               movie.Title,
+		"slug":                movie.Slug,
+		"releaseDate":         movie.ReleaseDate.Format("2006-01-02"),
+		"genre":               movie.Genre,
+		"genres":              movie.Genres,
+		"distributor":         movie.Distributor,
+		"budget":              movie.Budget,
+		"mpaRating":           movie.MpaRating,
+		"runtimeMinutes":      movie.RuntimeMinutes,
+		"originalLanguage":    movie.OriginalLanguage,
+		"spokenLanguages":     movie.SpokenLanguages,
+		"productionCountries": movie.ProductionCountries,
+		"synopsis":            movie.Synopsis,
+		"posterUrl":           movie.PosterURL,
+		"certifications":      movie.Certifications,
+		"releases":            movie.Releases,
+		"boxOffice":           movie.BoxOffice,
+		"alternateTitles":     movie.AlternateTitles,
+		"deletedAt":           movie.DeletedAt,
+	}
+}
+
+func diffMovies(before, after *domain.Movie) ([]string, map[string]json.RawMessage, map[string]json.RawMessage, error) {
+	prev := movieSnapshot(before)
+	next := movieSnapshot(after)
+
+	fields := make([]string, 0)
+	beforeDiff := make(map[string]json.RawMessage)
+	afterDiff := make(map[string]json.RawMessage)
+	for _, field := range movieRevisionFields {
+		prevJSON, err := json.Marshal(prev[field])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		nextJSON, err := json.Marshal(next[field])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if bytes.Equal(prevJSON, nextJSON) {
+			continue
+		}
+		fields = append(fields, field)
+		beforeDiff[field] = prevJSON
+		afterDiff[field] = nextJSON
+	}
+	return fields, beforeDiff, afterDiff, nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// slugConstraint is the unique index guarding movies.slug.
+const slugConstraint = "uq_movies_slug"
+
+// slugify lowercases the title and collapses every run of non letter/digit
+// characters into a single hyphen.
+func slugify(title string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingDash = true
+	}
+	return b.String()
+}
+
+// baseSlug builds the preferred slug for a release, e.g. "dune-2021".
+func baseSlug(title string, year int) string {
+	slug := slugify(title)
+	if slug == "" {
+		slug = "movie"
+	}
+	return fmt.Sprintf("%s-%d", slug, year)
+}
+
+// nextFreeSlug returns base, or base-N for the smallest free N. Slugs are
+// never reused across movies, so retired slugs in movie_slug_history count as
+// taken unless they belong to ownerID (a movie renamed back).
+func nextFreeSlug(ctx context.Context, tx pgx.Tx, base, ownerID string) (string, error) {
+	const query = `
+        SELECT slug FROM movies WHERE slug = $1 OR slug LIKE $2
+        UNION
+        SELECT slug FROM movie_slug_history
+        WHERE (slug = $1 OR slug LIKE $2) AND movie_id::text <> $3
+    `
+	rows, err := tx.Query(ctx, query, base, base+"-%", ownerID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	taken := make(map[string]struct{})
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return "", err
+		}
+		taken[slug] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if _, ok := taken[base]; !ok {
+		return base, nil
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", base, i)
+		if _, ok := taken[candidate]; !ok {
+			return candidate, nil
+		}
+	}
+}
+
+// reslug moves a movie to a new slug when its title or release year changed,
+// keeping the previous slug as a redirect.
+func reslug(ctx context.Context, tx pgx.Tx, before domain.Movie, title string, year int) (string, error) {
+	base := baseSlug(title, year)
+	if before.Slug == base || strings.HasPrefix(before.Slug, base+"-") && isNumeric(strings.TrimPrefix(before.Slug, base+"-")) {
+		return before.Slug, nil
+	}
+
+	slug, err := nextFreeSlug(ctx, tx, base, before.ID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM movie_slug_history WHERE slug = $1 AND movie_id = $2`, slug, before.ID); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO movie_slug_history (slug, movie_id) VALUES ($1, $2)`, before.Slug, before.ID); err != nil {
+		return "", err
+	}
+	return slug, nil
+}
+
+// GetBySlug fetches a live movie by slug. redirected is true when the slug is
+// a retired one and the caller should point clients at movie.Slug instead.
+func (r *MoviesRepository) GetBySlug(ctx context.Context, slug string) (movie domain.Movie, redirected bool, err error) {
+	query := fmt.Sprintf(`SELECT %s FROM movies WHERE slug = $1 AND deleted_at IS NULL`, movieColumns)
+	movie, err = scanMovie(r.pool.QueryRow(ctx, query, slug))
+	if err == nil {
+		return movie, false, nil
+	}
+	if err != pgx.ErrNoRows {
+		return domain.Movie{}, false, err
+	}
+
+	query = fmt.Sprintf(`
+        SELECT %s FROM movies
+        WHERE id = (SELECT movie_id FROM movie_slug_history WHERE slug = $1)
+          AND deleted_at IS NULL
+    `, movieColumns)
+	movie, err = scanMovie(r.pool.QueryRow(ctx, query, slug))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return domain.Movie{}, false, ErrNotFound
+		}
+		return domain.Movie{}, false, err
+	}
+	return movie, true, nil
+}
+
+func isSlugViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == slugConstraint
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+)
+
+// MovieSuggestion is a title offered while a user types.
+type MovieSuggestion struct {
+	ID          string
+	Slug        string
+	Title       string
+	ReleaseYear int
+	// PrefixMatch reports whether the title starts with the typed text.
+	PrefixMatch bool
+	// Score is the trigram similarity between the typed text and the title.
+	Score float64
+}
+
+// Suggest returns up to limit titles for type-ahead: titles starting with
+// prefix come first, followed by titles that are similar to it (catching
+// typos such as "Incepshun"). Both candidate sets are bounded index scans so
+// the query stays cheap on large catalogs.
+func (r *MoviesRepository) Suggest(ctx context.Context, prefix string, limit int) ([]MovieSuggestion, error) {
+	if limit <= 0 {
+		limit = 10
+	} else if limit > 25 {
+		limit = 25
+	}
+
+	const query = `
+        WITH prefixed AS (
+            SELECT id FROM movies
+            WHERE deleted_at IS NULL
+              AND lower(title) COLLATE "C" >= lower($1) COLLATE "C"
+              AND lower(title) COLLATE "C" < (lower($1) || chr(1114111)) COLLATE "C"
+            ORDER BY lower(title) COLLATE "C"
+            LIMIT $2
+        ), similar AS (
+            SELECT id FROM movies
+            WHERE deleted_at IS NULL AND ($1 % title OR $1 <% title)
+            ORDER BY greatest(similarity($1, title), word_similarity($1, title)) DESC
+            LIMIT $2
+        )
+        SELECT m.id, m.slug, m.title, m.release_year,
+               m.id IN (SELECT id FROM prefixed) AS prefix_match,
+               greatest(similarity($1, m.title), word_similarity($1, m.title))::float8 AS score
+        FROM movies m
+        WHERE m.id IN (SELECT id FROM prefixed UNION SELECT id FROM similar)
+        ORDER BY prefix_match DESC, score DESC, length(m.title), m.title, m.id
+        LIMIT $2
+    `
+	rows, err := r.pool.Query(ctx, query, prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := make([]MovieSuggestion, 0, limit)
+	for rows.Next() {
+		var s MovieSuggestion
+		if err := rows.Scan(&s.ID, &s.Slug, &s.Title, &s.ReleaseYear, &s.PrefixMatch, &s.Score); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
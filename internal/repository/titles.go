@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Clark-Hu/Robin-Camp-Clark/internal/domain"
+)
+
+// alternateTitlesColumn aggregates a movie's alternate titles into a JSON
+// array so they load with the movie row itself.
+const alternateTitlesColumn = `(
+        SELECT COALESCE(json_agg(json_build_object(
+                   'title', t.title, 'language', t.language, 'region', t.region, 'kind', t.kind
+               ) ORDER BY t.id), '[]'::json)
+        FROM movie_titles t
+        WHERE t.movie_id = movies.id
+    )`
+
+// alternateTitleMatch matches movies having an alternate title equal to the
+// placeholder it is formatted with, case-insensitively.
+const alternateTitleMatch = `EXISTS (SELECT 1 FROM movie_titles t WHERE t.movie_id = movies.id AND lower(t.title) = lower(%[1]s))`
+
+// replaceTitles swaps the full set of alternate titles of a movie.
+func replaceTitles(ctx context.Context, tx pgx.Tx, movieID string, titles []domain.AlternateTitle) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM movie_titles WHERE movie_id = $1`, movieID); err != nil {
+		return err
+	}
+	for _, title := range titles {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO movie_titles (movie_id, title, language, region, kind)
+            VALUES ($1, $2, $3, $4, $5)
+        `, movieID, title.Title, title.Language, title.Region, string(title.Kind))
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("duplicate alternate title %q: %w", title.Title, translateConstraintError(err))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadMovie re-reads a movie inside tx after its related rows changed.
+func reloadMovie(ctx context.Context, tx pgx.Tx, id string) (domain.Movie, error) {
+	query := fmt.Sprintf(`SELECT %s FROM movies WHERE id = $1`, movieColumns)
+	return scanMovie(tx.QueryRow(ctx, query, id))
+}